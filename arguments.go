@@ -0,0 +1,243 @@
+package dgrouter
+
+import (
+	"errors"
+	"strings"
+)
+
+// Argument errors
+var (
+	ErrMissingArgument   = errors.New("missing argument")
+	ErrTooManyArguments  = errors.New("too many arguments")
+	ErrInvalidArgument   = errors.New("invalid argument")
+	ErrArgumentAfterRest = errors.New("rest argument must be the last argument")
+)
+
+// ArgParseFunc converts a raw argument into a value
+//    ctx : the context type of the wrapping router, ex. *exrouter.Context
+//    raw : text of the argument
+type ArgParseFunc func(ctx interface{}, raw string) (interface{}, error)
+
+// Choice is a value suggested for an argument
+type Choice struct {
+	// Name is the text displayed to the user
+	Name string
+
+	// Value is the value used if the choice is picked
+	Value interface{}
+}
+
+// CompleteFunc suggests values for an argument while it is being typed
+//    ctx     : the context type of the wrapping router, ex. *exrouter.Context
+//    partial : text of the argument typed so far
+type CompleteFunc func(ctx interface{}, partial string) ([]Choice, error)
+
+// Argument describes an argument accepted by a route
+type Argument struct {
+	Name        string
+	Description string
+
+	// Type is a short name for the kind of value this argument accepts
+	// It is displayed in usage errors
+	Type string
+
+	// Required arguments must be supplied for the handler to be called
+	Required bool
+
+	// Rest arguments consume all of the remaining text of a command
+	Rest bool
+
+	// Parse converts the raw text of the argument into its value
+	// The raw string is returned as the value if it is nil
+	Parse ArgParseFunc
+
+	// Complete suggests values for the argument, ex. for slash command autocompletion
+	Complete CompleteFunc
+}
+
+// NewArgument returns a new required argument
+//    name  : name of the argument
+//    typ   : type of value the argument accepts
+//    parse : function used to convert the argument
+func NewArgument(name, typ string, parse ArgParseFunc) *Argument {
+	return &Argument{
+		Name:     name,
+		Type:     typ,
+		Required: true,
+		Parse:    parse,
+	}
+}
+
+// Optional marks this argument as optional
+func (a *Argument) Optional() *Argument {
+	a.Required = false
+	return a
+}
+
+// Desc sets this argument's description
+func (a *Argument) Desc(description string) *Argument {
+	a.Description = description
+	return a
+}
+
+// Autocomplete sets the function used to suggest values for this argument
+func (a *Argument) Autocomplete(fn CompleteFunc) *Argument {
+	a.Complete = fn
+	return a
+}
+
+// String returns the usage string of the argument
+// Required arguments are wrapped in <> and optional arguments in []
+func (a *Argument) String() string {
+	name := a.Name
+	if a.Rest {
+		name += "..."
+	}
+	if a.Required {
+		return "<" + name + ">"
+	}
+	return "[" + name + "]"
+}
+
+// ArgumentError is returned when an argument fails to parse
+type ArgumentError struct {
+	Route    *Route
+	Argument *Argument
+	Err      error
+}
+
+func (e *ArgumentError) Error() string {
+	if e.Argument == nil {
+		return e.Err.Error()
+	}
+	return e.Argument.Name + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// Args sets the argument schema of this route
+func (r *Route) Args(args ...*Argument) *Route {
+	r.Arguments = args
+	return r
+}
+
+// Path returns the names of this route and its parents
+// Starting from the top level route
+func (r *Route) Path() []string {
+	var path []string
+	for rt := r; rt != nil; rt = rt.Parent {
+		if rt.Name != "" {
+			path = append([]string{rt.Name}, path...)
+		}
+	}
+	return path
+}
+
+// Usage returns a usage line generated from this route's path and arguments
+// ex. ban <user> [reason...] [--days value]
+func (r *Route) Usage() string {
+	parts := r.Path()
+	for _, v := range r.Arguments {
+		parts = append(parts, v.String())
+	}
+	for _, v := range r.Flags {
+		parts = append(parts, v.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseArguments validates and converts args using the route's argument schema
+// It returns a map of argument names to their values
+//    ctx  : context passed to the argument parse functions
+//    args : arguments supplied after the route name
+func (r *Route) ParseArguments(ctx interface{}, args []string) (map[string]interface{}, error) {
+	return r.parseArguments(ctx, args, func(i int) string {
+		return strings.Join(args[i:], " ")
+	})
+}
+
+// ParseArgumentTokens is the same as ParseArguments, but rest arguments receive
+// the text exactly as it was written in content instead of the joined arguments.
+// Flags that were removed from the tokens are not included.
+// A rest argument made of a single token receives the token's value without its quotes
+//    ctx     : context passed to the argument parse functions
+//    content : text that the tokens were lexed from
+//    tokens  : tokens supplied after the route name
+func (r *Route) ParseArgumentTokens(ctx interface{}, content string, tokens []Token) (map[string]interface{}, error) {
+	args := make([]string, len(tokens))
+	for i, v := range tokens {
+		args[i] = v.Value
+	}
+	return r.parseArguments(ctx, args, func(i int) string {
+		if i == len(tokens)-1 {
+			return tokens[i].Value
+		}
+		return RawText(content, tokens[i:])
+	})
+}
+
+// RawText returns the text of tokens as it was written in content
+// The whitespace between tokens is kept, but text between them that is not part of a token,
+// such as flags that were removed, is replaced with a single space
+//    content : text that the tokens were lexed from
+//    tokens  : consecutive tokens of content
+func RawText(content string, tokens []Token) string {
+	var b strings.Builder
+	for i, v := range tokens {
+		if i > 0 {
+			gap := content[tokens[i-1].End:v.Start]
+			if strings.TrimSpace(gap) != "" {
+				gap = " "
+			}
+			b.WriteString(gap)
+		}
+		b.WriteString(content[v.Start:v.End])
+	}
+	return b.String()
+}
+
+// parseArguments parses args
+//    rest : returns the raw text of a rest argument starting at index i
+func (r *Route) parseArguments(ctx interface{}, args []string, rest func(i int) string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	for i, a := range r.Arguments {
+		if a.Rest && i != len(r.Arguments)-1 {
+			return nil, &ArgumentError{r, a, ErrArgumentAfterRest}
+		}
+
+		if i >= len(args) {
+			if a.Required {
+				return nil, &ArgumentError{r, a, ErrMissingArgument}
+			}
+			continue
+		}
+
+		raw := args[i]
+		if a.Rest {
+			raw = rest(i)
+		}
+
+		v, err := a.parse(ctx, raw)
+		if err != nil {
+			return nil, &ArgumentError{r, a, err}
+		}
+		values[a.Name] = v
+	}
+
+	if n := len(r.Arguments); n > 0 && !r.Arguments[n-1].Rest && len(args) > n {
+		return nil, &ArgumentError{Route: r, Err: ErrTooManyArguments}
+	}
+
+	return values, nil
+}
+
+func (a *Argument) parse(ctx interface{}, raw string) (interface{}, error) {
+	if a.Parse == nil {
+		return raw, nil
+	}
+	return a.Parse(ctx, raw)
+}
@@ -0,0 +1,345 @@
+// Package bind fills structs from command arguments using struct tags
+//
+// Positional arguments are bound with the arg tag, counting from the first argument
+// after the command name. The remaining arguments can be bound with arg:"rest".
+// Flags are bound with the flag tag, which takes a long name and an optional short name.
+// Flags are parsed with dgrouter.ParseFlags; boolean fields do not take a value.
+//
+// example:
+// type banParams struct {
+//     User   string        `arg:"0,required"`
+//     Reason string        `arg:"rest"`
+//     Days   int           `flag:"days,d"`
+//     Silent bool          `flag:"silent,s"`
+//     For    time.Duration `flag:"for"`
+// }
+// !ban someone --days 7 -s being rude --for 2d
+//
+// Durations and times are parsed with the timeparse package, so they can be written as 2d,
+// "3 hours" or "tomorrow 9am". Times without a zone are interpreted in the location given to ArgsIn or ValuesIn
+package bind
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/timeparse"
+)
+
+// Errors
+var (
+	ErrNotStructPointer = errors.New("bind: value must be a pointer to a struct")
+	ErrRequired         = errors.New("argument is required")
+)
+
+// FieldError is an error binding a single field
+type FieldError struct {
+	// Name of the argument or flag
+	Name string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is a list of errors encountered while binding
+type Errors []error
+
+func (e Errors) Error() string {
+	var s []string
+	for _, v := range e {
+		s = append(s, v.Error())
+	}
+	return strings.Join(s, "\n")
+}
+
+type field struct {
+	index    int
+	name     string
+	position int
+	rest     bool
+	required bool
+	long     string
+	short    string
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Args binds command arguments to the struct pointed to by v
+// All errors encountered are returned together as Errors
+//    args : arguments supplied after the command name
+//    v    : pointer to a struct to fill
+func Args(args []string, v interface{}) error {
+	return ArgsIn(args, time.UTC, v)
+}
+
+// ArgsIn is the same as Args, but interprets times in the given location
+//    args : arguments supplied after the command name
+//    loc  : timezone of the user
+//    v    : pointer to a struct to fill
+func ArgsIn(args []string, loc *time.Location, v interface{}) error {
+	rv, fields, err := parseStruct(v)
+	if err != nil {
+		return err
+	}
+
+	var errs Errors
+	positional, flags, err := splitFlags(args, fields, rv)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return bindValues(rv, fields, positional, flags, loc, errs)
+}
+
+// Values binds arguments that have already been separated from their flags
+// It is used when flags were parsed by the router, see dgrouter.ParseFlags
+//    positional : positional arguments supplied after the command name
+//    flags      : map of flag names to their values
+//    v          : pointer to a struct to fill
+func Values(positional []string, flags map[string][]string, v interface{}) error {
+	return ValuesIn(positional, flags, time.UTC, v)
+}
+
+// ValuesIn is the same as Values, but interprets times in the given location
+//    positional : positional arguments supplied after the command name
+//    flags      : map of flag names to their values
+//    loc        : timezone of the user
+//    v          : pointer to a struct to fill
+func ValuesIn(positional []string, flags map[string][]string, loc *time.Location, v interface{}) error {
+	rv, fields, err := parseStruct(v)
+	if err != nil {
+		return err
+	}
+	return bindValues(rv, fields, positional, flags, loc, nil)
+}
+
+func parseStruct(v interface{}) (reflect.Value, []field, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return rv, nil, ErrNotStructPointer
+	}
+	rv = rv.Elem()
+
+	fields, err := parseFields(rv.Type())
+	return rv, fields, err
+}
+
+func bindValues(rv reflect.Value, fields []field, positional []string, flags map[string][]string, loc *time.Location, errs Errors) error {
+	for _, f := range fields {
+		if f.long != "" {
+			if vals, ok := flags[f.long]; ok {
+				if err := setValues(rv.Field(f.index), vals, loc); err != nil {
+					errs = append(errs, &FieldError{f.long, err})
+				}
+			}
+			continue
+		}
+
+		var vals []string
+		switch {
+		case f.rest:
+			if start := restStart(fields); start < len(positional) {
+				vals = positional[start:]
+			}
+		case f.position < len(positional):
+			vals = positional[f.position : f.position+1]
+		}
+
+		if len(vals) == 0 {
+			if f.required {
+				errs = append(errs, &FieldError{f.name, ErrRequired})
+			}
+			continue
+		}
+
+		if err := setValues(rv.Field(f.index), vals, loc); err != nil {
+			errs = append(errs, &FieldError{f.name, err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// parseFields reads the arg and flag tags of a struct type
+func parseFields(t reflect.Type) ([]field, error) {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		if tag, ok := sf.Tag.Lookup("flag"); ok {
+			names := strings.Split(tag, ",")
+			f := field{index: i, name: names[0], long: names[0]}
+			if len(names) > 1 {
+				f.short = names[1]
+			}
+			fields = append(fields, f)
+			continue
+		}
+
+		tag, ok := sf.Tag.Lookup("arg")
+		if !ok {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		f := field{index: i, name: strings.ToLower(sf.Name)}
+		if opts[0] == "rest" {
+			f.rest = true
+		} else {
+			n, err := strconv.Atoi(opts[0])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("bind: invalid arg tag on field %s: %q", sf.Name, tag)
+			}
+			f.position = n
+		}
+		for _, o := range opts[1:] {
+			if o == "required" {
+				f.required = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// restStart returns the index of the first argument not bound to a position
+func restStart(fields []field) int {
+	start := 0
+	for _, f := range fields {
+		if f.long == "" && !f.rest && f.position+1 > start {
+			start = f.position + 1
+		}
+	}
+	return start
+}
+
+// splitFlags separates the flags declared by fields from the positional arguments
+func splitFlags(args []string, fields []field, rv reflect.Value) ([]string, map[string][]string, error) {
+	var flags []*dgrouter.Flag
+	for _, f := range fields {
+		if f.long != "" {
+			flags = append(flags, &dgrouter.Flag{
+				Name:  f.long,
+				Short: f.short,
+				Bool:  rv.Field(f.index).Kind() == reflect.Bool,
+			})
+		}
+	}
+
+	if len(flags) == 0 {
+		return args, nil, nil
+	}
+	return dgrouter.ParseFlags(args, flags)
+}
+
+// setValues sets a field from one or more raw values
+// Slices receive every value, strings receive the values joined by spaces,
+// and other types receive the last value
+func setValues(v reflect.Value, vals []string, loc *time.Location) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, raw := range vals {
+			if err := setValue(s.Index(i), raw, loc); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	if v.Kind() == reflect.String {
+		return setValue(v, strings.Join(vals, " "), loc)
+	}
+	return setValue(v, vals[len(vals)-1], loc)
+}
+
+// setValue converts raw to the type of v and sets it
+//    loc : location times are interpreted in
+func setValue(v reflect.Value, raw string, loc *time.Location) error {
+	switch v.Type() {
+	case durationType:
+		d, err := timeparse.ParseDuration(raw)
+		if err == timeparse.ErrDurationOutOfRange {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := timeparse.ParseTime(raw, time.Now(), loc)
+		if err == timeparse.ErrDurationOutOfRange {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("invalid time %q", raw)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), raw, loc); err != nil {
+			return err
+		}
+		v.Set(p)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package bind_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Necroforger/dgrouter/bind"
+)
+
+type banParams struct {
+	User   string        `arg:"0,required"`
+	Count  int           `arg:"1"`
+	Reason string        `arg:"rest"`
+	Days   int           `flag:"days,d"`
+	Silent bool          `flag:"silent,s"`
+	For    time.Duration `flag:"for"`
+}
+
+func TestBind(t *testing.T) {
+	var p banParams
+	err := bind.Args([]string{"someone", "3", "--days", "7", "-s", "--for=1h", "being", "rude"}, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.User != "someone" || p.Count != 3 || p.Reason != "being rude" {
+		t.Errorf("unexpected positional arguments: %+v", p)
+	}
+	if p.Days != 7 || !p.Silent || p.For != time.Hour {
+		t.Errorf("unexpected flags: %+v", p)
+	}
+}
+
+func TestBindTerminator(t *testing.T) {
+	var p banParams
+	if err := bind.Args([]string{"someone", "1", "--", "--days", "-s"}, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Reason != "--days -s" || p.Days != 0 || p.Silent {
+		t.Errorf("flags after terminator were parsed: %+v", p)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	var p banParams
+	err := bind.Args([]string{"--days", "many", "--unknown"}, &p)
+	errs, ok := err.(bind.Errors)
+	if !ok {
+		t.Fatalf("expected bind.Errors, got %v", err)
+	}
+
+	// unknown flag, invalid days and missing user
+	if len(errs) != 3 {
+		t.Errorf("expected 3 errors, got %d: %v", len(errs), errs)
+	}
+
+	if err := bind.Args(nil, p); err != bind.ErrNotStructPointer {
+		t.Errorf("expected ErrNotStructPointer, got %v", err)
+	}
+}
+
+func TestBindTime(t *testing.T) {
+	var p struct {
+		For time.Duration `flag:"for"`
+		At  time.Time     `flag:"at"`
+	}
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	if err := bind.ArgsIn([]string{"--for", "2d", "--at", "2024-02-01 10:00"}, loc, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.For != time.Hour*48 || !p.At.Equal(time.Date(2024, 2, 1, 10, 0, 0, 0, loc)) {
+		t.Errorf("unexpected values: %+v", p)
+	}
+
+	if err := bind.Args([]string{"--for", "soon"}, &p); err == nil {
+		t.Error("expected an invalid duration error")
+	}
+}
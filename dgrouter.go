@@ -57,22 +57,73 @@ func (r *Route) OnMatch(name string, matcher func(string) bool, handler HandlerF
 		return rt
 	}
 
+	rt := r.newRoute(name, matcher, handler)
+	r.AddRoute(rt)
+	return rt
+}
+
+// OnKind adds a handler for a kind of event other than commands
+// The route is added to Events instead of Routes
+// If a route of the same kind and name exists, it is returned instead
+//    kind    : kind of event the route handles
+//    name    : name of the route to add
+//    matcher : matcher function used to match the route
+//    handler : handler function for the route
+func (r *Route) OnKind(kind, name string, matcher func(string) bool, handler HandlerFunc) *Route {
+	for _, v := range r.Events {
+		if v.Kind == kind && v.Name == name {
+			return v
+		}
+	}
+	return r.AddEvent(kind, name, matcher, handler)
+}
+
+// AddEvent adds a handler for a kind of event other than commands
+// Unlike OnKind, a new route is always added, even if one with the same name exists.
+// This is used for events that can be handled by several routes, see FindAllKind
+//    kind    : kind of event the route handles
+//    name    : name of the route to add
+//    matcher : matcher function used to match the route
+//    handler : handler function for the route
+func (r *Route) AddEvent(kind, name string, matcher func(string) bool, handler HandlerFunc) *Route {
+	rt := r.newRoute(name, matcher, handler)
+	rt.Kind = kind
+	rt.Parent = r
+	r.Events = append(r.Events, rt)
+	return rt
+}
+
+// OnPattern adds a handler for a kind of event that is matched by a pattern
+// The parameters of the pattern can be retrieved with the route's Pattern
+// ex. OnPattern("component", "vote:{poll}:{option}", handler)
+//    kind    : kind of event the route handles
+//    pattern : pattern used to match the route, see Pattern
+//    handler : handler function for the route
+func (r *Route) OnPattern(kind, pattern string, handler HandlerFunc) *Route {
+	p := MustCompilePattern(pattern)
+	rt := r.OnKind(kind, pattern, p.MatchString, handler)
+	rt.Pattern = p
+	return rt
+}
+
+// newRoute creates a route that inherits this route's category and middleware
+// Routes without a handler, such as command groups, are left without one
+func (r *Route) newRoute(name string, matcher func(string) bool, handler HandlerFunc) *Route {
 	nhandler := handler
 
 	// Add middleware to the handler
-	for _, v := range r.Middleware {
-		nhandler = v(nhandler)
+	if nhandler != nil {
+		for _, v := range r.Middleware {
+			nhandler = v(nhandler)
+		}
 	}
 
-	rt := &Route{
+	return &Route{
 		Name:     name,
 		Category: r.Category,
 		Handler:  nhandler,
 		Matcher:  matcher,
 	}
-
-	r.AddRoute(rt)
-	return rt
 }
 
 // AddRoute adds a route to the router
@@ -113,6 +164,19 @@ func (r *Route) Find(name string) *Route {
 	return nil
 }
 
+// FindKind finds an event route of the given kind that matches value
+// It will return nil if nothing is found
+//    kind  : kind of route to find
+//    value : value to match, ex. the custom ID of a button
+func (r *Route) FindKind(kind, value string) *Route {
+	for _, v := range r.Events {
+		if v.Kind == kind && v.Matcher(value) {
+			return v
+		}
+	}
+	return nil
+}
+
 // FindFull a full path of routes by searching through their subroutes
 // Until the deepest match is found.
 // It will return the route matched and the depth it was found at
@@ -133,6 +197,20 @@ func (r *Route) FindFull(args ...string) (*Route, int) {
 	return nr, i
 }
 
+// FindAllKind finds every event route of the given kind that matches value
+// Events such as reactions may be handled by several routes
+//    kind  : kind of route to find
+//    value : value to match
+func (r *Route) FindAllKind(kind, value string) []*Route {
+	var routes []*Route
+	for _, v := range r.Events {
+		if v.Kind == kind && v.Matcher(value) {
+			routes = append(routes, v)
+		}
+	}
+	return routes
+}
+
 // New returns a new route
 func New() *Route {
 	return &Route{
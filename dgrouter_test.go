@@ -2,6 +2,7 @@ package dgrouter_test
 
 import (
 	"log"
+	"strings"
 	"testing"
 
 	"github.com/Necroforger/dgrouter"
@@ -29,3 +30,202 @@ func TestRouter(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestArguments(t *testing.T) {
+	r := dgrouter.New()
+
+	upper := func(_ interface{}, raw string) (interface{}, error) {
+		return strings.ToUpper(raw), nil
+	}
+
+	rt := r.On("ban", nil).Args(
+		dgrouter.NewArgument("user", "string", nil),
+		dgrouter.NewArgument("days", "string", upper).Optional(),
+		&dgrouter.Argument{Name: "reason", Rest: true},
+	)
+
+	if u := rt.Usage(); u != "ban <user> [days] [reason...]" {
+		t.Errorf("unexpected usage: %s", u)
+	}
+
+	values, err := rt.ParseArguments(nil, []string{"someone", "seven", "spam", "bot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["user"] != "someone" || values["days"] != "SEVEN" || values["reason"] != "spam bot" {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	if _, err := rt.ParseArguments(nil, []string{}); err == nil {
+		t.Error("expected missing argument error")
+	}
+
+	if _, err := r.On("kick", nil).Args(dgrouter.NewArgument("user", "string", nil)).ParseArguments(nil, []string{"a", "b"}); err == nil {
+		t.Error("expected too many arguments error")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	flags := []*dgrouter.Flag{
+		dgrouter.NewFlag("days", "d"),
+		dgrouter.NewFlag("reason", "r"),
+		dgrouter.NewBoolFlag("silent", "s"),
+		dgrouter.NewBoolFlag("force", "f"),
+	}
+
+	args, values, err := dgrouter.ParseFlags(
+		[]string{"@user", "--days", "7", "-sf", "--reason=spam bot", "-5", "--", "--days"},
+		flags,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(args, ",") != "@user,-5,--days" {
+		t.Errorf("unexpected positional arguments: %v", args)
+	}
+	if values["days"][0] != "7" || values["reason"][0] != "spam bot" || values["silent"] == nil || values["force"] == nil {
+		t.Errorf("unexpected flags: %v", values)
+	}
+
+	if _, _, err := dgrouter.ParseFlags([]string{"--nope"}, flags); err == nil {
+		t.Error("expected unknown flag error")
+	}
+	if _, _, err := dgrouter.ParseFlags([]string{"--days"}, flags); err == nil {
+		t.Error("expected missing value error")
+	}
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{`say it's "fine"`, []string{"say", "it's", "fine"}},
+		{"say  \n\t spaced   out", []string{"say", "spaced", "out"}},
+		{"say “smart quotes” ‘single’", []string{"say", "smart quotes", "single"}},
+		{`say 'single "nested"' "double 'nested'"`, []string{"say", `single "nested"`, "double 'nested'"}},
+		{`say escaped\ space \"quote\" \\`, []string{"say", "escaped space", `"quote"`, `\`}},
+		{`say "an \"escaped\" quote" ""`, []string{"say", `an "escaped" quote`, ""}},
+		{"eval ```go\nfmt.Println(\"a  b\")\n``` after", []string{"eval", "```go\nfmt.Println(\"a  b\")\n```", "after"}},
+		{"tag `inline \"code\"`", []string{"tag", "`inline \"code\"`"}},
+		{`ban --reason="spam bot" -n='a b' x`, []string{"ban", "--reason=spam bot", "-n=a b", "x"}},
+		{`say a="b c"`, []string{"say", `a="b`, `c"`}},
+		{"", nil},
+	}
+
+	for _, v := range tests {
+		got, err := dgrouter.Lex(v.in)
+		if err != nil {
+			t.Errorf("Lex(%q): %v", v.in, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(v.want, "|") || len(got) != len(v.want) {
+			t.Errorf("Lex(%q) = %q, want %q", v.in, got, v.want)
+		}
+	}
+
+	for _, v := range []string{`say "unclosed`, "eval ```unclosed", "say “unclosed"} {
+		if _, err := dgrouter.Lex(v); err == nil {
+			t.Errorf("Lex(%q): expected an error", v)
+		}
+	}
+}
+
+func TestLexCode(t *testing.T) {
+	tokens, err := dgrouter.LexTokens("eval ```go\nfmt.Println(\"hi\")\n``` `inline` ```plain text```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 4 {
+		t.Fatalf("expected 4 tokens, got %d", len(tokens))
+	}
+
+	if tk := tokens[1]; !tk.Code || tk.Lang != "go" || tk.Body != `fmt.Println("hi")` {
+		t.Errorf("unexpected code block token: %+v", tk)
+	}
+	if tk := tokens[2]; !tk.Code || tk.Lang != "" || tk.Body != "inline" {
+		t.Errorf("unexpected inline code token: %+v", tk)
+	}
+	if tk := tokens[3]; !tk.Code || tk.Lang != "" || tk.Body != "plain text" {
+		t.Errorf("unexpected code block token: %+v", tk)
+	}
+	if tokens[0].Code {
+		t.Error("plain argument marked as code")
+	}
+}
+
+func TestTokenOffsets(t *testing.T) {
+	content := `tag create  "my tag"   some   text`
+	tokens, err := dgrouter.LexTokens(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tk := tokens[2]; content[tk.Start:tk.End] != `"my tag"` || tk.Value != "my tag" {
+		t.Errorf("unexpected token offsets: %+v", tk)
+	}
+
+	r := dgrouter.New()
+	rt := r.On("create", nil).Args(
+		dgrouter.NewArgument("name", "string", nil),
+		&dgrouter.Argument{Name: "text", Rest: true},
+	)
+
+	values, err := rt.ParseArgumentTokens(nil, content, tokens[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["name"] != "my tag" || values["text"] != "some   text" {
+		t.Errorf("unexpected values: %q", values)
+	}
+
+	// Flags removed from between the arguments are not part of the rest argument
+	content = "u spam  --days 7 bot -s"
+	tokens, err = dgrouter.LexTokens(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ban := r.On("ban", nil).Args(
+		dgrouter.NewArgument("user", "user", nil),
+		&dgrouter.Argument{Name: "reason", Rest: true},
+	)
+	tokens, _, err = dgrouter.ParseFlagTokens(tokens, []*dgrouter.Flag{dgrouter.NewFlag("days", "d"), dgrouter.NewBoolFlag("silent", "s")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err = ban.ParseArgumentTokens(nil, content, tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["reason"] != "spam bot" {
+		t.Errorf("unexpected reason: %q", values["reason"])
+	}
+}
+
+func TestPattern(t *testing.T) {
+	p := dgrouter.MustCompilePattern("vote:{poll}:{option}")
+
+	params, ok := p.Match("vote:12:yes")
+	if !ok || params["poll"] != "12" || params["option"] != "yes" {
+		t.Errorf("unexpected match: %v %v", params, ok)
+	}
+	for _, v := range []string{"vote:12", "vote::yes", "poll:12:yes"} {
+		if _, ok := p.Match(v); ok {
+			t.Errorf("expected %q not to match", v)
+		}
+	}
+
+	for _, v := range []string{"vote:{}", "vote:{poll", "vote:poll}"} {
+		if _, err := dgrouter.CompilePattern(v); err == nil {
+			t.Errorf("expected %q to be invalid", v)
+		}
+	}
+
+	r := dgrouter.New()
+	r.On("vote", nil)
+	rt := r.OnPattern("component", "vote:{poll}:{option}", nil)
+	if len(r.Routes) != 1 || r.FindKind("component", "vote:1:yes") != rt {
+		t.Error("expected pattern routes to be kept apart from commands")
+	}
+}
@@ -1,12 +1,12 @@
 package disgordrouter
 
 import (
-	"bytes"
-	"encoding/csv"
 	"strings"
+
+	"github.com/Necroforger/dgrouter"
 )
 
-// separator is the separator character for splitting arguments
+// separator is the separator character for joining arguments
 const separator = ' '
 
 // Args is a helper type for dealing with command arguments
@@ -28,13 +28,44 @@ func (a Args) After(n int) string {
 	return ""
 }
 
+// CodeBlock returns the body and language tag of the first code block in the arguments
+// Inline code spans are used if there is no code block. The body is returned
+// exactly as it was written in the message
+// ex. ```go\nfmt.Println("hi")\n``` returns `fmt.Println("hi")` and "go"
+func (a Args) CodeBlock() (body, lang string) {
+	var inline string
+	for _, v := range a {
+		l, b, ok := dgrouter.ParseCode(v)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(v, "```") {
+			return b, l
+		}
+		if inline == "" {
+			inline = b
+		}
+	}
+	return inline, ""
+}
+
 // ParseArgs parses command arguments
+// Arguments are separated by whitespace and can be grouped with quotes, see dgrouter.Lex
+// If a quote or code block is left unclosed, no arguments are returned
+//
+// Deprecated: ParseArgs can not report unclosed quotes, use LexArgs instead
 func ParseArgs(content string) Args {
-	cv := csv.NewReader(bytes.NewBufferString(content))
-	cv.Comma = separator
-	fields, err := cv.Read()
+	args, _ := LexArgs(content)
+	return args
+}
+
+// LexArgs parses command arguments
+// Arguments are separated by whitespace and can be grouped with quotes, see dgrouter.Lex
+// If a quote or code block is left unclosed, a *dgrouter.LexError is returned
+func LexArgs(content string) (Args, error) {
+	args, err := dgrouter.Lex(content)
 	if err != nil {
-		return strings.Split(content, string(separator))
+		return nil, err
 	}
-	return fields
+	return args, nil
 }
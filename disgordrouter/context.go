@@ -8,6 +8,7 @@ import (
 	"github.com/andersfylling/disgord"
 
 	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/bind"
 )
 
 // Context represents a command context
@@ -40,6 +41,16 @@ func (c *Context) Get(key string) interface{} {
 	return nil
 }
 
+// Bind fills the struct pointed to by v with the command's arguments
+// Fields are bound using the arg and flag struct tags, see the bind package for details
+func (c *Context) Bind(v interface{}) error {
+	var args []string
+	if len(c.Args) > 1 {
+		args = c.Args[1:]
+	}
+	return bind.Args(args, v)
+}
+
 // Reply replies to the sender with the given message
 func (c *Context) Reply(args ...interface{}) (*disgord.Message, error) {
 	return c.Ses.SendMsg(context.Background(), c.Msg.ChannelID, fmt.Sprint(args...))
@@ -74,16 +74,69 @@ func nickMention(id string) string {
 // it creates a context from a message, finds its route, and executes the handler
 // it looks for a message prefix which is either the prefix specified or the message is prefixed
 // with a bot mention
-//    s            : discordgo session to pass to context
+// Messages from bots, webhooks, the system and the bot itself are ignored, see Filter
+// If a quote is left unclosed, the sender is sent the error and the route's usage, and the error is returned
+//    s            : disgord session to pass to context
 //    prefix       : prefix you want the bot to respond to
 //    botID        : user ID of the bot to allow you to substitute the bot ID for a prefix
 //    m            : discord message to pass to context
 func (r *Route) FindAndExecute(s disgord.Session, prefix string, botID disgord.Snowflake, m *disgord.Message) error {
+	return r.FindAndExecuteFilter(s, prefix, botID, m, Filter{})
+}
+
+// Filter controls which messages are ignored by FindAndExecuteFilter
+// The zero value ignores messages from bots, webhooks, the system and the bot itself
+type Filter struct {
+	// AllowBots allows every route to be called by bots
+	// Individual routes can allow bots with dgrouter.Route.Bots
+	AllowBots bool
+
+	// AllowSelf allows the bot to call its own commands
+	AllowSelf bool
+
+	// AllowWebhooks allows webhooks to call commands
+	AllowWebhooks bool
+
+	// AllowSystem allows system messages to call commands
+	// Replies to other messages are not system messages
+	AllowSystem bool
+}
+
+// ignored returns true if the message is from a source the filter ignores
+func (f Filter) ignored(m *disgord.Message, botID disgord.Snowflake) bool {
+	switch {
+	case !f.AllowWebhooks && m.WebhookID != 0:
+		return true
+	case !f.AllowSystem && m.Type != disgord.MessageTypeDefault && m.Type != disgord.MessageTypeReply:
+		return true
+	case !f.AllowSelf && m.Author != nil && m.Author.ID == botID:
+		return true
+	}
+	return false
+}
+
+// FindAndExecuteFilter is the same as FindAndExecute, but uses a filter to choose which messages are ignored
+//    s            : disgord session to pass to context
+//    prefix       : prefix you want the bot to respond to
+//    botID        : user ID of the bot to allow you to substitute the bot ID for a prefix
+//    m            : discord message to pass to context
+//    filter       : filter for ignoring messages
+func (r *Route) FindAndExecuteFilter(s disgord.Session, prefix string, botID disgord.Snowflake, m *disgord.Message, filter Filter) error {
 	var pf string
 	botIDStr := botID.String()
 
+	if filter.ignored(m, botID) {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+
+	// Bots are only ignored once it is known whether their route allows them
+	fromBot := !filter.AllowBots && m.Author != nil && m.Author.Bot
+
 	// If the message content is only a bot mention and the mention route is not nil, send the mention route
 	if r.Default != nil && m.Content == mention(botIDStr) || r.Default != nil && m.Content == nickMention(botIDStr) {
+		if fromBot && !r.Default.BotsAllowed() {
+			return dgrouter.ErrCouldNotFindRoute
+		}
 		r.Default.Handler(NewContext(s, m, []string{""}, r.Default))
 		return nil
 	}
@@ -108,11 +161,26 @@ func (r *Route) FindAndExecute(s disgord.Session, prefix string, botID disgord.S
 	}
 
 	command := strings.TrimPrefix(m.Content, pf)
-	args := ParseArgs(command)
+	args, err := LexArgs(command)
+	lerr, unclosed := err.(*dgrouter.LexError)
+	if unclosed {
+		// The text before the unclosed quote can still name the route
+		args, _ = LexArgs(command[:lerr.Offset])
+	} else if err != nil {
+		return err
+	}
 
 	if rt, depth := r.FindFull(args...); depth > 0 {
+		if fromBot && !rt.BotsAllowed() {
+			return dgrouter.ErrCouldNotFindRoute
+		}
 		args = append([]string{strings.Join(args[:depth], string(separator))}, args[depth:]...)
-		rt.Handler(NewContext(s, m, args, rt))
+		ctx := NewContext(s, m, args, rt)
+		if unclosed {
+			ctx.Reply("error: ", lerr, "\nusage: `", pf, rt.Usage(), "`")
+			return lerr
+		}
+		rt.Handler(ctx)
 	} else {
 		return dgrouter.ErrCouldNotFindRoute
 	}
@@ -0,0 +1,74 @@
+package disgordrouter_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/disgordrouter"
+	"github.com/andersfylling/disgord"
+)
+
+func TestFilter(t *testing.T) {
+	r := disgordrouter.New()
+
+	var called bool
+	r.On("ping", func(ctx *disgordrouter.Context) {
+		called = true
+	})
+
+	tests := []struct {
+		msg    *disgord.Message
+		filter disgordrouter.Filter
+		called bool
+	}{
+		{&disgord.Message{Content: "!ping", Author: &disgord.User{ID: 1}}, disgordrouter.Filter{}, true},
+		{&disgord.Message{Content: "!ping", Author: &disgord.User{ID: 1}, Type: disgord.MessageTypeReply}, disgordrouter.Filter{}, true},
+		{&disgord.Message{Content: "!ping", Author: &disgord.User{ID: 1}, Type: disgord.MessageTypeGuildMemberJoin}, disgordrouter.Filter{}, false},
+		{&disgord.Message{Content: "!ping", Author: &disgord.User{ID: 1}, Type: disgord.MessageTypeGuildMemberJoin}, disgordrouter.Filter{AllowSystem: true}, true},
+		{&disgord.Message{Content: "!ping", Author: &disgord.User{ID: 1}, WebhookID: 3}, disgordrouter.Filter{}, false},
+		{&disgord.Message{Content: "!ping", Author: &disgord.User{ID: 2}}, disgordrouter.Filter{}, false},
+		{&disgord.Message{Content: "!ping", Author: &disgord.User{ID: 1, Bot: true}}, disgordrouter.Filter{}, false},
+		{&disgord.Message{Content: "!ping", Author: &disgord.User{ID: 1, Bot: true}}, disgordrouter.Filter{AllowBots: true}, true},
+	}
+
+	for i, v := range tests {
+		called = false
+		err := r.FindAndExecuteFilter(nil, "!", 2, v.msg, v.filter)
+		if called != v.called {
+			t.Errorf("test %d: expected called to be %v", i, v.called)
+		}
+		if !v.called && err != dgrouter.ErrCouldNotFindRoute {
+			t.Errorf("test %d: expected ErrCouldNotFindRoute, got %v", i, err)
+		}
+	}
+}
+
+// replySession records the messages sent through it
+type replySession struct {
+	disgord.Session
+	sent []string
+}
+
+func (s *replySession) SendMsg(ctx context.Context, channelID disgord.Snowflake, data ...interface{}) (*disgord.Message, error) {
+	s.sent = append(s.sent, fmt.Sprint(data...))
+	return &disgord.Message{}, nil
+}
+
+func TestUnclosedQuotes(t *testing.T) {
+	r := disgordrouter.New()
+
+	var called bool
+	r.On("say", func(ctx *disgordrouter.Context) { called = true })
+
+	s := &replySession{}
+	err := r.FindAndExecute(s, "!", 2, &disgord.Message{Content: `!say "hello`, Author: &disgord.User{ID: 1}})
+	if _, ok := err.(*dgrouter.LexError); !ok || called {
+		t.Errorf("expected a LexError without calling the handler, got %v", err)
+	}
+	if len(s.sent) != 1 || !strings.HasPrefix(s.sent[0], "error: ") || !strings.Contains(s.sent[0], "usage: `!say`") {
+		t.Errorf("expected the error to be sent with the usage, got %q", s.sent)
+	}
+}
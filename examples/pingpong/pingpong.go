@@ -3,10 +3,12 @@ package main
 import (
 	"flag"
 	"log"
+	"time"
 
 	"github.com/Necroforger/dgrouter"
 
 	"github.com/Necroforger/dgrouter/exrouter"
+	"github.com/Necroforger/dgrouter/exrouter/arg"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -38,7 +40,12 @@ func main() {
 	// Match the regular expression user(name)?
 	router.OnMatch("username", dgrouter.NewRegexMatcher("user(name)?"), func(ctx *exrouter.Context) {
 		ctx.Reply("Your username is " + ctx.Msg.Author.Username)
-	})
+	}).Desc("returns your username")
+
+	// Accept the output of other commands, ex. !avatar | say
+	router.On("say", func(ctx *exrouter.Context) {
+		ctx.Reply(ctx.Params.String("text"))
+	}).Args(arg.Rest("text").Desc("text to repeat")).Desc("repeats the given text").Pipe()
 
 	router.Default = router.On("help", func(ctx *exrouter.Context) {
 		var text = ""
@@ -49,8 +56,23 @@ func main() {
 	}).Desc("prints this help menu")
 
 	// Add message handler
-	s.AddHandler(func(_ *discordgo.Session, m *discordgo.MessageCreate) {
-		router.FindAndExecute(s, *fPrefix, s.State.User.ID, m.Message)
+	d := exrouter.NewDispatcher(router, exrouter.StaticPrefix(*fPrefix))
+	d.MaxPipeline = 3
+	d.PipelineTimeout = time.Second * 10
+	s.AddHandler(d.Handle)
+
+	// The same routes can be used as slash commands
+	s.AddHandler(d.HandleInteraction)
+
+	// Register the routes as slash commands once connected
+	// Only the commands that changed since the last run are updated
+	s.AddHandlerOnce(func(s *discordgo.Session, r *discordgo.Ready) {
+		report, err := exrouter.NewCommandSync(s, r.User.ID, "").SyncRoutes(router)
+		if err != nil {
+			log.Println("error registering slash commands: ", err)
+			return
+		}
+		log.Println(report)
 	})
 
 	err = s.Open()
@@ -22,6 +22,7 @@ import (
 	"sync"
 
 	"github.com/Necroforger/dgrouter/exrouter"
+	"github.com/Necroforger/dgrouter/exrouter/arg"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -85,6 +86,9 @@ func trimExtension(p string) string {
 func createRouter(s *discordgo.Session) *exrouter.Route {
 	router := exrouter.New()
 
+	// Names of the sounds that can be played
+	var sounds []string
+
 	// Create playback functions
 	files, err := ioutil.ReadDir(*fSoundDir)
 	if err != nil {
@@ -98,19 +102,38 @@ func createRouter(s *discordgo.Session) *exrouter.Route {
 			var data = [][]string{}
 			decodeFromFile(path.Join(*fSoundDir, v.Name()), &data)
 			for _, d := range data {
-				router.On(trimExtension(d[0]), createYoutubeFunction(d[1])).Desc("Plays " + d[1])
+				router.On(trimExtension(d[0]), createYoutubeFunction(d[1])).Desc("Plays " + d[1]).GuildOnly()
+				sounds = append(sounds, trimExtension(d[0]))
 			}
 			continue
 		}
 		router.On(
 			trimExtension(v.Name()),
 			createMusicFunction(filepath.Join(*fSoundDir, v.Name())),
-		).Desc("plays " + v.Name())
+		).Desc("plays " + v.Name()).GuildOnly()
+		sounds = append(sounds, trimExtension(v.Name()))
 	}
 
+	// Play a sound by name, which lets the sound be picked from a list when used as a slash command
+	router.On("play", func(ctx *exrouter.Context) {
+		name := ctx.Params.String("sound")
+		for _, v := range sounds {
+			if v == name {
+				reply(ctx, "playing ", name)
+				router.Find(name).Handler(ctx)
+				return
+			}
+		}
+		reply(ctx, "unknown sound: ", name)
+	}).Args(
+		arg.String("sound").Desc("name of the sound to play").Autocomplete(
+			arg.Suggestions(func(ctx *exrouter.Context) []string { return sounds }),
+		),
+	).Desc("plays a sound").GuildOnly()
+
 	router.On("stop", func(ctx *exrouter.Context) {
 		stopStreaming(ctx.Msg.GuildID)
-	}).Desc("Stops the currently running stream")
+	}).Desc("Stops the currently running stream").GuildOnly()
 
 	router.On("leave", func(ctx *exrouter.Context) {
 		s.Lock()
@@ -121,11 +144,11 @@ func createRouter(s *discordgo.Session) *exrouter.Route {
 			}
 		}
 		s.Unlock()
-	}).Desc("Leaves the current voice channel")
+	}).Desc("Leaves the current voice channel").GuildOnly()
 
 	router.On("yt", func(ctx *exrouter.Context) {
-		createYoutubeFunction(ctx.Args.Get(1))(ctx)
-	}).Desc("plays a youtube link").Alias("youtube")
+		createYoutubeFunction(ctx.Params.String("url"))(ctx)
+	}).Args(arg.String("url")).Desc("plays a youtube link").Alias("youtube").GuildOnly()
 
 	// Create help route and set it to the default route for bot mentions
 	router.Default = router.On("help", func(ctx *exrouter.Context) {
@@ -137,6 +160,10 @@ func createRouter(s *discordgo.Session) *exrouter.Route {
 			}
 		}
 		for _, v := range router.Routes {
+			// Leave out the sounds when the help menu is used in direct messages
+			if ctx.CheckScope(v) != nil {
+				continue
+			}
 			text += fmt.Sprintf("%-"+strconv.Itoa(maxlen+5)+"s:    %s\n", v.Name, v.Description)
 		}
 		reply(ctx, "```"+text+"```")
@@ -192,6 +219,7 @@ func main() {
 
 	var rmu sync.RWMutex
 	router := createRouter(s)
+	dispatcher := exrouter.NewDispatcher(router, nil)
 
 	// Rebuild the router when the sound directory is modified
 	if *fWatch {
@@ -199,6 +227,7 @@ func main() {
 			rmu.Lock()
 			defer rmu.Unlock()
 			router = createRouter(s)
+			dispatcher = exrouter.NewDispatcher(router, nil)
 		})
 		defer watcher.Close()
 	}
@@ -211,6 +240,35 @@ func main() {
 		go router.FindAndExecute(s, *fPrefix, s.State.User.ID, m.Message)
 	})
 
+	// Add interaction handler for the play slash command
+	s.AddHandler(func(_ *discordgo.Session, i *discordgo.InteractionCreate) {
+		rmu.RLock()
+		d := dispatcher
+		rmu.RUnlock()
+		d.HandleInteraction(s, i)
+	})
+
+	// Register the slash commands once connected
+	// Sound names are not always valid command names, so they are played with /play
+	s.AddHandlerOnce(func(s *discordgo.Session, r *discordgo.Ready) {
+		var commands []*discordgo.ApplicationCommand
+		for _, name := range []string{"play", "stop", "leave"} {
+			cmd, err := exrouter.ApplicationCommand(router.Find(name))
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			commands = append(commands, cmd)
+		}
+
+		report, err := exrouter.NewCommandSync(s, r.User.ID, "").Sync(commands)
+		if err != nil {
+			log.Println("error registering slash commands: ", err)
+			return
+		}
+		log.Println(report)
+	})
+
 	err = s.Open()
 	if err != nil {
 		log.Fatal(err)
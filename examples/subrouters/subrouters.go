@@ -47,9 +47,7 @@ func main() {
 	}).Desc("prints this help menu")
 
 	// Add message handler
-	s.AddHandler(func(_ *discordgo.Session, m *discordgo.MessageCreate) {
-		router.FindAndExecute(s, *fPrefix, s.State.User.ID, m.Message)
-	})
+	s.AddHandler(exrouter.NewDispatcher(router, exrouter.StaticPrefix(*fPrefix)).Handle)
 
 	err = s.Open()
 	if err != nil {
@@ -7,12 +7,13 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/boltdb/bolt"
 
+	"github.com/Necroforger/dgrouter"
 	"github.com/Necroforger/dgrouter/exrouter"
+	"github.com/Necroforger/dgrouter/exrouter/arg"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -26,45 +27,59 @@ var database *bolt.DB
 
 const bucket = "roles"
 
-func monitorDatabase(s *discordgo.Session, done chan int) {
-	t := time.NewTicker(time.Second * 1)
-	for {
-		select {
-		case <-t.C:
-			database.Update(func(tx *bolt.Tx) error {
-				b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+// removeExpiredRoles removes the roles that expired since the last tick
+func removeExpiredRoles(ctx *exrouter.Context, t *exrouter.Tick) {
+	database.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var r RoleExpiration
+			err := json.Unmarshal(v, &r)
+			if err != nil {
+				log.Println("Error unmarshaling JSON: ", err)
+				return err
+			}
+			if t.Time.After(r.Expires) {
+				err := ctx.Ses.GuildMemberRoleRemove(r.GuildID, r.UserID, r.RoleID)
 				if err != nil {
+					log.Println("error removing role")
 					return err
 				}
+				err = b.Delete(k)
+				if err != nil {
+					log.Println("error deleting: ", err)
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
 
-				b.ForEach(func(k, v []byte) error {
-					var r RoleExpiration
-					err := json.Unmarshal(v, &r)
-					if err != nil {
-						log.Println("Error unmarshaling JSON: ", err)
-						return err
-					}
-					if time.Now().After(r.Expires) {
-						err := s.GuildMemberRoleRemove(r.GuildID, r.UserID, r.RoleID)
-						if err != nil {
-							log.Println("error removing role")
-							return err
-						}
-						err = b.Delete(k)
-						if err != nil {
-							log.Println("error deleting: ", err)
-							return err
-						}
-					}
-					return nil
-				})
-
-				return nil
-			})
-		case <-done:
-			return
+// forgetMember deletes the role expirations of a member that left the guild
+func forgetMember(ctx *exrouter.Context, m *discordgo.GuildMemberRemove) {
+	database.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
 		}
-	}
+
+		var keys [][]byte
+		b.ForEach(func(k, v []byte) error {
+			var r RoleExpiration
+			if json.Unmarshal(v, &r) == nil && r.GuildID == m.GuildID && r.UserID == m.User.ID {
+				keys = append(keys, k)
+			}
+			return nil
+		})
+		for _, k := range keys {
+			b.Delete(k)
+		}
+		return nil
+	})
 }
 
 func main() {
@@ -80,16 +95,16 @@ func main() {
 		log.Fatal(err)
 	}
 
-	done := make(chan int) // close this channel when you are done monitoring
-	go monitorDatabase(s, done)
-
 	err = s.Open()
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	r := exrouter.New()
-	r.On("setrole", cmdRole).Desc("usage: setrole [userid] [role_name] [duration in seconds]")
+	r.On("setrole", cmdRole).
+		Args(arg.Member("member"), arg.Role("role"), durationArg("duration").Optional()).
+		Desc("sets a role for the given duration in seconds or as a duration, ex. setrole @user @role 1h30m").
+		GuildOnly()
 
 	// Create help route and set it to the default route for bot mentions
 	r.Default = r.On("help", func(ctx *exrouter.Context) {
@@ -101,20 +116,43 @@ func main() {
 			}
 		}
 		for _, v := range r.Routes {
+			if ctx.CheckScope(v) != nil {
+				continue
+			}
 			text += fmt.Sprintf("%-"+strconv.Itoa(maxlen+5)+"s:    %s\n", v.Name, v.Description)
 		}
 		ctx.Reply("```" + text + "```")
 	}).Desc("prints this help menu")
 
-	s.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
-		r.FindAndExecute(s, *fPrefix, s.State.User.ID, m.Message)
-	})
+	// Gateway and custom events are routed like commands
+	r.OnEvent(removeExpiredRoles)
+	r.OnEvent(forgetMember)
+
+	d := exrouter.NewDispatcher(r, exrouter.StaticPrefix(*fPrefix))
+	s.AddHandler(d.Handle)
+	s.AddHandler(d.HandleEvent)
+
+	// Check for expired roles every second
+	d.Tick(s, "roles", time.Second)
 
 	log.Println("bot is running...")
 	// Prevent the bot from exiting
 	<-make(chan struct{})
 }
 
+// durationArg accepts a number of seconds, ex. 90, or a duration such as 1h30m
+func durationArg(name string) *dgrouter.Argument {
+	a := arg.Duration(name)
+	parse := a.Parse
+	a.Parse = func(ctx interface{}, raw string) (interface{}, error) {
+		if n, err := strconv.Atoi(raw); err == nil {
+			return time.Second * time.Duration(n), nil
+		}
+		return parse(ctx, raw)
+	}
+	return a
+}
+
 // RoleExpiration ...
 type RoleExpiration struct {
 	RoleID  string
@@ -148,53 +186,28 @@ func saveRoleExpiration(data RoleExpiration) error {
 }
 
 func cmdRole(ctx *exrouter.Context) {
-	if ctx.Args.Get(1) == "" || ctx.Args.Get(2) == "" {
-		ctx.Reply(ctx.Route.Description)
-		return
-	}
+	member := ctx.Params.Member("member")
+	role := ctx.Params.Role("role")
 
-	guild, err := ctx.Guild(ctx.Msg.GuildID)
-	if err != nil {
-		ctx.Reply("Guild not found")
-		return
-	}
-
-	role := findRoleByName(guild, ctx.Args.Get(2))
-	if role == nil {
-		ctx.Reply("Role not found")
-		return
-	}
-
-	err = ctx.Ses.GuildMemberRoleAdd(ctx.Msg.GuildID, ctx.Args.Get(1), role.ID)
+	err := ctx.Ses.GuildMemberRoleAdd(ctx.Msg.GuildID, member.User.ID, role.ID)
 	if err != nil {
 		ctx.Reply("Could not add role to member: ", err)
 		return
 	}
 
-	var duration int
-	if n, err := strconv.Atoi(ctx.Args.Get(3)); err == nil {
-		duration = n
-	} else {
-		duration = 10
+	duration := time.Second * 10
+	if ctx.Params.Has("duration") {
+		duration = ctx.Params.Duration("duration")
 	}
 
-	expires := time.Now().Add(time.Second * time.Duration(duration))
+	expires := time.Now().Add(duration)
 
 	saveRoleExpiration(RoleExpiration{
 		Expires: expires,
 		GuildID: ctx.Msg.GuildID,
-		UserID:  ctx.Args.Get(1),
+		UserID:  member.User.ID,
 		RoleID:  role.ID,
 	})
 
 	ctx.Reply("Set temporary role for user\nIt will expire on " + expires.String())
 }
-
-func findRoleByName(guild *discordgo.Guild, name string) *discordgo.Role {
-	for _, v := range guild.Roles {
-		if strings.ToLower(v.Name) == name {
-			return v
-		}
-	}
-	return nil
-}
@@ -0,0 +1,140 @@
+// Package arg provides argument types for declaring exrouter route argument schemas
+//
+// example:
+// router.On("ban", cmdBan).Args(arg.User("target"), arg.Duration("for").Optional(), arg.Rest("reason"))
+package arg
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/exrouter"
+	"github.com/Necroforger/dgrouter/timeparse"
+)
+
+// Errors
+var (
+	ErrNotANumber   = errors.New("not a number")
+	ErrNotABoolean  = errors.New("not a boolean")
+	ErrNotADuration = errors.New("not a duration")
+	ErrNotATime     = errors.New("not a time")
+)
+
+// Func wraps a parse function that takes an exrouter.Context
+func Func(fn func(ctx *exrouter.Context, raw string) (interface{}, error)) dgrouter.ArgParseFunc {
+	return func(ctx interface{}, raw string) (interface{}, error) {
+		return fn(ctx.(*exrouter.Context), raw)
+	}
+}
+
+// Complete wraps a complete function that takes an exrouter.Context
+func Complete(fn func(ctx *exrouter.Context, partial string) ([]dgrouter.Choice, error)) dgrouter.CompleteFunc {
+	return func(ctx interface{}, partial string) ([]dgrouter.Choice, error) {
+		return fn(ctx.(*exrouter.Context), partial)
+	}
+}
+
+// Suggestions returns a complete function that suggests the values containing the text typed so far
+// Matching ignores case, and values that start with the text are suggested first
+// The values are sent to discord as the type of the argument, values of other types are left out
+//    values : returns the values that can be suggested
+func Suggestions(values func(ctx *exrouter.Context) []string) dgrouter.CompleteFunc {
+	return Complete(func(ctx *exrouter.Context, partial string) ([]dgrouter.Choice, error) {
+		partial = strings.ToLower(partial)
+
+		var prefixed, contains []dgrouter.Choice
+		for _, v := range values(ctx) {
+			lower := strings.ToLower(v)
+			switch {
+			case strings.HasPrefix(lower, partial):
+				prefixed = append(prefixed, dgrouter.Choice{Name: v, Value: v})
+			case strings.Contains(lower, partial):
+				contains = append(contains, dgrouter.Choice{Name: v, Value: v})
+			}
+		}
+		return append(prefixed, contains...), nil
+	})
+}
+
+// String returns an argument that accepts any text
+func String(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "string", nil)
+}
+
+// Rest returns an argument that consumes the remaining text of the command
+// It must be the last argument of a route
+func Rest(name string) *dgrouter.Argument {
+	a := dgrouter.NewArgument(name, "string", nil)
+	a.Rest = true
+	return a
+}
+
+// Int returns an argument that accepts an integer
+func Int(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "int", func(_ interface{}, raw string) (interface{}, error) {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, ErrNotANumber
+		}
+		return n, nil
+	})
+}
+
+// Float returns an argument that accepts a floating point number
+func Float(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "float", func(_ interface{}, raw string) (interface{}, error) {
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, ErrNotANumber
+		}
+		return n, nil
+	})
+}
+
+// Bool returns an argument that accepts a boolean such as true, false, yes, no, on or off
+func Bool(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "bool", func(_ interface{}, raw string) (interface{}, error) {
+		switch strings.ToLower(raw) {
+		case "true", "yes", "y", "on", "1":
+			return true, nil
+		case "false", "no", "n", "off", "0":
+			return false, nil
+		}
+		return nil, ErrNotABoolean
+	})
+}
+
+// Duration returns an argument that accepts a duration such as 1h30m, 2d or "3 hours"
+// The value is a time.Duration
+func Duration(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "duration", func(_ interface{}, raw string) (interface{}, error) {
+		d, err := timeparse.ParseDuration(raw)
+		if err == timeparse.ErrDurationOutOfRange {
+			return nil, err
+		}
+		if err != nil {
+			return nil, ErrNotADuration
+		}
+		return d, nil
+	})
+}
+
+// Time returns an argument that accepts a point in time such as "tomorrow 9am",
+// "in 3 hours", an ISO-8601 timestamp or a discord timestamp
+// Times are interpreted in the timezone returned by Context.Timezone
+// The value is a time.Time
+func Time(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "time", Func(func(ctx *exrouter.Context, raw string) (interface{}, error) {
+		t, err := timeparse.ParseTime(raw, time.Now(), ctx.Timezone())
+		if err == timeparse.ErrDurationOutOfRange {
+			return nil, err
+		}
+		if err != nil {
+			return nil, ErrNotATime
+		}
+		return t, nil
+	}))
+}
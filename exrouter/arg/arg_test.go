@@ -0,0 +1,26 @@
+package arg
+
+import (
+	"testing"
+
+	"github.com/Necroforger/dgrouter/exrouter"
+)
+
+func TestSuggestions(t *testing.T) {
+	complete := Suggestions(func(ctx *exrouter.Context) []string {
+		return []string{"Interlude", "airhorn", "rain", "tada"}
+	})
+
+	choices, err := complete(&exrouter.Context{}, "In")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, v := range choices {
+		names = append(names, v.Name)
+	}
+	if len(names) != 2 || names[0] != "Interlude" || names[1] != "rain" {
+		t.Errorf("unexpected suggestions: %v", names)
+	}
+}
@@ -0,0 +1,336 @@
+package arg
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/exrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord entity errors
+var (
+	ErrNotAUser     = errors.New("user not found")
+	ErrNotAMember   = errors.New("member not found")
+	ErrNotAChannel  = errors.New("channel not found")
+	ErrNotARole     = errors.New("role not found")
+	ErrNotAnEmoji   = errors.New("emoji not found")
+	ErrGuildOnly    = errors.New("can only be used in a server")
+	ErrOutsideGuild = errors.New("does not belong to this server")
+)
+
+// User returns an argument that accepts a user mention, ID or name
+// Names are only resolved against the members of the guild the message was sent in
+// The value is a *discordgo.User
+func User(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "user", Func(func(ctx *exrouter.Context, raw string) (interface{}, error) {
+		if id, ok := parseMention(raw, "<@!", "<@"); ok {
+			return resolveUser(ctx, id)
+		}
+
+		m, err := findMember(ctx, raw)
+		if err != nil {
+			return nil, ErrNotAUser
+		}
+		return m.User, nil
+	}))
+}
+
+// Member returns an argument that accepts a member mention, ID or name
+// The member must belong to the guild the message was sent in
+// The value is a *discordgo.Member
+func Member(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "member", Func(func(ctx *exrouter.Context, raw string) (interface{}, error) {
+		if ctx.Msg.GuildID == "" {
+			return nil, ErrGuildOnly
+		}
+
+		if id, ok := parseMention(raw, "<@!", "<@"); ok {
+			m, err := ctx.Member(ctx.Msg.GuildID, id)
+			if err != nil {
+				return nil, ErrNotAMember
+			}
+			return m, nil
+		}
+
+		return findMember(ctx, raw)
+	}))
+}
+
+// Channel returns an argument that accepts a channel mention, ID or name
+// The channel must belong to the guild the message was sent in
+// The value is a *discordgo.Channel
+func Channel(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "channel", Func(func(ctx *exrouter.Context, raw string) (interface{}, error) {
+		if id, ok := parseMention(raw, "<#"); ok {
+			ch, err := ctx.Channel(id)
+			if err != nil {
+				return nil, ErrNotAChannel
+			}
+			// Channels outside of a guild can only be the channel the message was sent in
+			if ch.GuildID != ctx.Msg.GuildID || ch.GuildID == "" && ch.ID != ctx.Msg.ChannelID {
+				return nil, ErrOutsideGuild
+			}
+			return ch, nil
+		}
+
+		if ctx.Msg.GuildID == "" {
+			return nil, ErrNotAChannel
+		}
+
+		channels, err := guildChannels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(raw, "#")
+		for _, v := range channels {
+			if strings.EqualFold(v.Name, name) {
+				return v, nil
+			}
+		}
+		return nil, ErrNotAChannel
+	}))
+}
+
+// Role returns an argument that accepts a role mention, ID or name
+// The role must belong to the guild the message was sent in
+// The value is a *discordgo.Role
+func Role(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "role", Func(func(ctx *exrouter.Context, raw string) (interface{}, error) {
+		if ctx.Msg.GuildID == "" {
+			return nil, ErrGuildOnly
+		}
+
+		if id, ok := parseMention(raw, "<@&"); ok {
+			r, err := ctx.Role(ctx.Msg.GuildID, id)
+			if err != nil {
+				return nil, ErrNotARole
+			}
+			return r, nil
+		}
+
+		guild, err := ctx.Guild(ctx.Msg.GuildID)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(raw, "@")
+		for _, v := range guild.Roles {
+			if strings.EqualFold(v.Name, name) {
+				return v, nil
+			}
+		}
+		return nil, ErrNotARole
+	}))
+}
+
+// Emoji returns an argument that accepts a custom emoji, emoji ID, emoji name or unicode emoji
+// Custom emoji must belong to the guild the message was sent in
+// The value is a *discordgo.Emoji; unicode emoji only have their Name set
+func Emoji(name string) *dgrouter.Argument {
+	return dgrouter.NewArgument(name, "emoji", Func(func(ctx *exrouter.Context, raw string) (interface{}, error) {
+		if isUnicodeEmoji(raw) {
+			return &discordgo.Emoji{Name: raw}, nil
+		}
+
+		if ctx.Msg.GuildID == "" {
+			return nil, ErrGuildOnly
+		}
+
+		if e, ok := parseEmoji(raw); ok {
+			if ge, err := ctx.Ses.State.Emoji(ctx.Msg.GuildID, e.ID); err == nil {
+				return ge, nil
+			}
+			raw = e.ID
+		}
+
+		guild, err := ctx.Guild(ctx.Msg.GuildID)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.Trim(raw, ":")
+		for _, v := range guild.Emojis {
+			if v.ID == raw || strings.EqualFold(v.Name, name) {
+				return v, nil
+			}
+		}
+		if isSnowflake(raw) {
+			return nil, ErrOutsideGuild
+		}
+		return nil, ErrNotAnEmoji
+	}))
+}
+
+// resolveUser finds a user by ID
+// It checks the message mentions, the guild's members and then the restapi
+func resolveUser(ctx *exrouter.Context, id string) (*discordgo.User, error) {
+	for _, v := range ctx.Msg.Mentions {
+		if v.ID == id {
+			return v, nil
+		}
+	}
+
+	if ctx.Msg.GuildID != "" {
+		if m, err := ctx.Ses.State.Member(ctx.Msg.GuildID, id); err == nil {
+			return m.User, nil
+		}
+	}
+
+	u, err := ctx.Ses.User(id)
+	if err != nil {
+		return nil, ErrNotAUser
+	}
+	return u, nil
+}
+
+// findMember finds a member of the message's guild by ID, username, username#discriminator or nickname
+func findMember(ctx *exrouter.Context, raw string) (*discordgo.Member, error) {
+	if ctx.Msg.GuildID == "" {
+		return nil, ErrGuildOnly
+	}
+
+	if isSnowflake(raw) {
+		if m, err := ctx.Member(ctx.Msg.GuildID, raw); err == nil {
+			return m, nil
+		}
+	}
+
+	match := func(m *discordgo.Member) bool {
+		if m.User == nil {
+			return false
+		}
+		return strings.EqualFold(m.User.Username, raw) ||
+			strings.EqualFold(m.Nick, raw) ||
+			strings.EqualFold(m.User.Username+"#"+m.User.Discriminator, raw)
+	}
+
+	if m := findStateMember(ctx, match); m != nil {
+		return m, nil
+	}
+
+	members, err := ctx.Ses.GuildMembersSearch(ctx.Msg.GuildID, raw, 10)
+	if err != nil {
+		return nil, ErrNotAMember
+	}
+	for _, v := range members {
+		if match(v) {
+			return v, nil
+		}
+	}
+	return nil, ErrNotAMember
+}
+
+// findStateMember returns the first member in the state that satisfies match
+func findStateMember(ctx *exrouter.Context, match func(*discordgo.Member) bool) *discordgo.Member {
+	guild, err := ctx.Ses.State.Guild(ctx.Msg.GuildID)
+	if err != nil {
+		return nil
+	}
+
+	ctx.Ses.State.RLock()
+	defer ctx.Ses.State.RUnlock()
+	for _, v := range guild.Members {
+		if match(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// guildChannels returns the channels of the message's guild from the state or restapi
+func guildChannels(ctx *exrouter.Context) ([]*discordgo.Channel, error) {
+	if guild, err := ctx.Ses.State.Guild(ctx.Msg.GuildID); err == nil {
+		return guild.Channels, nil
+	}
+	return ctx.Ses.GuildChannels(ctx.Msg.GuildID)
+}
+
+// parseMention returns the ID from a mention or raw snowflake
+//    prefixes : mention prefixes to accept, ex. "<@!", "<@"
+func parseMention(raw string, prefixes ...string) (string, bool) {
+	if isSnowflake(raw) {
+		return raw, true
+	}
+	if !strings.HasSuffix(raw, ">") {
+		return "", false
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(raw, p) {
+			id := raw[len(p) : len(raw)-1]
+			return id, isSnowflake(id)
+		}
+	}
+	return "", false
+}
+
+// parseEmoji parses a custom emoji in the form <:name:id> or <a:name:id>
+func parseEmoji(raw string) (*discordgo.Emoji, bool) {
+	if !strings.HasPrefix(raw, "<") || !strings.HasSuffix(raw, ">") {
+		return nil, false
+	}
+	parts := strings.Split(raw[1:len(raw)-1], ":")
+	if len(parts) != 3 || (parts[0] != "" && parts[0] != "a") || !isSnowflake(parts[2]) {
+		return nil, false
+	}
+	return &discordgo.Emoji{
+		ID:       parts[2],
+		Name:     parts[1],
+		Animated: parts[0] == "a",
+	}, true
+}
+
+// isSnowflake returns true if s looks like a discord ID
+// Short numbers are not considered IDs so that names like "2024" can still be matched
+func isSnowflake(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil && len(s) >= 15
+}
+
+// emojiRanges are the unicode ranges that contain emoji
+var emojiRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00a9, Hi: 0x00ae, Stride: 5}, // © ®
+		{Lo: 0x203c, Hi: 0x2049, Stride: 13},
+		{Lo: 0x2122, Hi: 0x2139, Stride: 23},
+		{Lo: 0x2194, Hi: 0x21aa, Stride: 1}, // arrows
+		{Lo: 0x231a, Hi: 0x23ff, Stride: 1}, // watches, hourglasses and media controls
+		{Lo: 0x24c2, Hi: 0x24c2, Stride: 1},
+		{Lo: 0x25aa, Hi: 0x25fe, Stride: 1}, // geometric shapes
+		{Lo: 0x2600, Hi: 0x27bf, Stride: 1}, // miscellaneous symbols and dingbats
+		{Lo: 0x2934, Hi: 0x2935, Stride: 1},
+		{Lo: 0x2b05, Hi: 0x2b55, Stride: 1}, // arrows, squares and stars
+		{Lo: 0x3030, Hi: 0x303d, Stride: 13},
+		{Lo: 0x3297, Hi: 0x3299, Stride: 2},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1faff, Stride: 1}, // pictographs, flags, skin tones and more
+	},
+	LatinOffset: 1,
+}
+
+// isEmojiModifier returns true for characters that join or modify emoji
+func isEmojiModifier(r rune) bool {
+	return r == 0x200d || r == 0xfe0e || r == 0xfe0f || r == 0x20e3 || r >= 0xe0020 && r <= 0xe007f
+}
+
+// isUnicodeEmoji returns true if s is made of emoji and emoji modifiers, ex. 👍🏽, ❤️ or 1️⃣
+func isUnicodeEmoji(s string) bool {
+	// Keycaps start with a digit, # or *
+	if strings.HasSuffix(s, "\u20e3") && strings.ContainsAny(s[:1], "0123456789#*") {
+		return strings.Trim(s[1:], "\ufe0f\u20e3") == ""
+	}
+
+	base := false
+	for _, r := range s {
+		switch {
+		case unicode.Is(emojiRanges, r):
+			base = true
+		case isEmojiModifier(r):
+		default:
+			return false
+		}
+	}
+	return base
+}
@@ -0,0 +1,132 @@
+package arg
+
+import (
+	"testing"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/exrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseMention(t *testing.T) {
+	const id = "222222222222222222"
+
+	tests := []struct {
+		raw      string
+		prefixes []string
+		ok       bool
+	}{
+		{"<@" + id + ">", []string{"<@!", "<@"}, true},
+		{"<@!" + id + ">", []string{"<@!", "<@"}, true},
+		{id, []string{"<#"}, true},
+		{"<#" + id + ">", []string{"<#"}, true},
+		{"<@&" + id + ">", []string{"<@&"}, true},
+		{"<@&" + id + ">", []string{"<#"}, false},
+		{"<#notanid>", []string{"<#"}, false},
+		{"general", []string{"<#"}, false},
+	}
+
+	for _, v := range tests {
+		got, ok := parseMention(v.raw, v.prefixes...)
+		if ok != v.ok || ok && got != id {
+			t.Errorf("parseMention(%q) = %q, %v", v.raw, got, ok)
+		}
+	}
+}
+
+func TestParseEmoji(t *testing.T) {
+	e, ok := parseEmoji("<a:party:222222222222222222>")
+	if !ok || e.Name != "party" || e.ID != "222222222222222222" || !e.Animated {
+		t.Errorf("unexpected emoji: %+v", e)
+	}
+
+	if _, ok := parseEmoji("<@222222222222222222>"); ok {
+		t.Error("parsed a mention as an emoji")
+	}
+
+	for _, v := range []string{"👍🏽", "❤️", "⭐", "🇺🇸", "1️⃣", "👨‍👩‍👧"} {
+		if !isUnicodeEmoji(v) {
+			t.Errorf("expected %q to be an emoji", v)
+		}
+	}
+	for _, v := range []string{"thumbsup", "—", "€", "é", "1", "\ufe0f", ""} {
+		if isUnicodeEmoji(v) {
+			t.Errorf("expected %q not to be an emoji", v)
+		}
+	}
+}
+
+// stateContext returns a context for a message sent in a guild held by the session's state
+func stateContext(guildID string) *exrouter.Context {
+	s, _ := discordgo.New("Bot token")
+	s.State = discordgo.NewState()
+	s.State.GuildAdd(&discordgo.Guild{
+		ID:       "111111111111111111",
+		Emojis:   []*discordgo.Emoji{{ID: "222222222222222222", Name: "party"}},
+		Roles:    []*discordgo.Role{{ID: "333333333333333333", Name: "Admin"}},
+		Channels: []*discordgo.Channel{{ID: "444444444444444444", GuildID: "111111111111111111", Name: "general"}},
+		Members:  []*discordgo.Member{{GuildID: "111111111111111111", User: &discordgo.User{ID: "555555555555555555", Username: "gopher"}}},
+	})
+	s.State.GuildAdd(&discordgo.Guild{
+		ID:       "666666666666666666",
+		Emojis:   []*discordgo.Emoji{{ID: "777777777777777777", Name: "elsewhere"}},
+		Channels: []*discordgo.Channel{{ID: "888888888888888888", GuildID: "666666666666666666", Name: "other"}},
+	})
+	return exrouter.NewContext(s, &discordgo.Message{GuildID: guildID, ChannelID: "444444444444444444"}, nil, nil)
+}
+
+func TestConverters(t *testing.T) {
+	ctx := stateContext("111111111111111111")
+	dm := stateContext("")
+
+	tests := []struct {
+		arg  func(string) *dgrouter.Argument
+		ctx  *exrouter.Context
+		raw  string
+		id   string
+		name string
+		err  error
+	}{
+		{Emoji, ctx, "<:party:222222222222222222>", "222222222222222222", "party", nil},
+		{Emoji, ctx, ":party:", "222222222222222222", "party", nil},
+		{Emoji, ctx, "⭐", "", "⭐", nil},
+		{Emoji, ctx, "<:elsewhere:777777777777777777>", "", "", ErrOutsideGuild},
+		{Emoji, ctx, "—", "", "", ErrNotAnEmoji},
+		{Emoji, dm, "<:party:222222222222222222>", "", "", ErrGuildOnly},
+		{Role, ctx, "<@&333333333333333333>", "333333333333333333", "Admin", nil},
+		{Role, ctx, "admin", "333333333333333333", "Admin", nil},
+		{Channel, ctx, "<#444444444444444444>", "444444444444444444", "general", nil},
+		{Channel, ctx, "#general", "444444444444444444", "general", nil},
+		{Channel, ctx, "<#888888888888888888>", "", "", ErrOutsideGuild},
+		{Member, ctx, "<@555555555555555555>", "555555555555555555", "gopher", nil},
+		{Member, ctx, "gopher", "555555555555555555", "gopher", nil},
+		{Member, dm, "gopher", "", "", ErrGuildOnly},
+	}
+
+	for _, v := range tests {
+		a := v.arg("value")
+		got, err := a.Parse(v.ctx, v.raw)
+		if err != v.err {
+			t.Errorf("%s %q: expected error %v, got %v", a.Type, v.raw, v.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		var id, name string
+		switch x := got.(type) {
+		case *discordgo.Emoji:
+			id, name = x.ID, x.Name
+		case *discordgo.Role:
+			id, name = x.ID, x.Name
+		case *discordgo.Channel:
+			id, name = x.ID, x.Name
+		case *discordgo.Member:
+			id, name = x.User.ID, x.User.Username
+		}
+		if id != v.id || name != v.name {
+			t.Errorf("%s %q: unexpected value %+v", a.Type, v.raw, got)
+		}
+	}
+}
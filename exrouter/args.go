@@ -1,12 +1,14 @@
 package exrouter
 
 import (
-	"bytes"
-	"encoding/csv"
 	"strings"
+	"time"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/timeparse"
 )
 
-// separator is the separator character for splitting arguments
+// separator is the separator character for joining arguments
 const separator = ' '
 
 // Args is a helper type for dealing with command arguments
@@ -28,13 +30,103 @@ func (a Args) After(n int) string {
 	return ""
 }
 
+// Duration parses a duration starting at argument n, ex. 1h30m or "3 hours"
+// It returns the duration and the number of arguments it was written with,
+// or timeparse.ErrDurationOutOfRange if the duration is too long
+func (a Args) Duration(n int) (time.Duration, int, error) {
+	err := timeparse.ErrInvalidDuration
+	for i := lastTimeArg(a, n); i > n; i-- {
+		d, e := timeparse.ParseDuration(strings.Join(a[n:i], " "))
+		if e == nil {
+			return d, i - n, nil
+		}
+		if e == timeparse.ErrDurationOutOfRange {
+			err = e
+		}
+	}
+	return 0, 0, err
+}
+
+// Time parses a point in time starting at argument n, ex. "tomorrow 9am" or "in 2 days"
+// It returns the time and the number of arguments it was written with
+//    n   : index of the first argument
+//    loc : timezone of the user, see Context.Timezone
+func (a Args) Time(n int, loc *time.Location) (time.Time, int, error) {
+	now := time.Now()
+	err := timeparse.ErrInvalidTime
+	for i := lastTimeArg(a, n); i > n; i-- {
+		t, e := timeparse.ParseTime(strings.Join(a[n:i], " "), now, loc)
+		if e == nil {
+			return t, i - n, nil
+		}
+		if e == timeparse.ErrDurationOutOfRange {
+			err = e
+		}
+	}
+	return time.Time{}, 0, err
+}
+
+// maxTimeArgs is the largest number of arguments a time or duration is parsed from
+const maxTimeArgs = 6
+
+// lastTimeArg returns the end index of the arguments a time starting at n can be parsed from
+func lastTimeArg(a Args, n int) int {
+	if n < 0 {
+		return n
+	}
+	if n+maxTimeArgs < len(a) {
+		return n + maxTimeArgs
+	}
+	return len(a)
+}
+
+// tokenArgs returns the values of lexed tokens
+func tokenArgs(tokens []dgrouter.Token) Args {
+	args := make(Args, len(tokens))
+	for i, v := range tokens {
+		args[i] = v.Value
+	}
+	return args
+}
+
+// CodeBlock returns the body and language tag of the first code block in the arguments
+// Inline code spans are used if there is no code block. The body is returned
+// exactly as it was written in the message
+// ex. ```go\nfmt.Println("hi")\n``` returns `fmt.Println("hi")` and "go"
+func (a Args) CodeBlock() (body, lang string) {
+	var inline string
+	for _, v := range a {
+		l, b, ok := dgrouter.ParseCode(v)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(v, "```") {
+			return b, l
+		}
+		if inline == "" {
+			inline = b
+		}
+	}
+	return inline, ""
+}
+
 // ParseArgs parses command arguments
+// Arguments are separated by whitespace and can be grouped with quotes, see dgrouter.Lex
+// If a quote or code block is left unclosed, no arguments are returned
+//
+// Deprecated: ParseArgs can not report unclosed quotes, use LexArgs instead
 func ParseArgs(content string) Args {
-	cv := csv.NewReader(bytes.NewBufferString(content))
-	cv.Comma = separator
-	fields, err := cv.Read()
+	args, _ := LexArgs(content)
+	return args
+}
+
+// LexArgs parses command arguments
+// Arguments are separated by whitespace and can be grouped with quotes, see dgrouter.Lex
+// If a quote or code block is left unclosed, a *dgrouter.LexError is returned
+func LexArgs(content string) (Args, error) {
+	args, err := dgrouter.Lex(content)
 	if err != nil {
-		return strings.Split(content, string(separator))
+		return nil, err
 	}
-	return fields
+	return args, nil
 }
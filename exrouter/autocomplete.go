@@ -0,0 +1,136 @@
+package exrouter
+
+import (
+	"fmt"
+	"math"
+	"runtime/debug"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Autocomplete limits
+const (
+	MaxChoices          = 25
+	MaxChoiceNameLength = 100
+)
+
+// autocomplete answers an autocomplete interaction using the Complete function
+// of the argument being typed. Middleware and handlers are not called.
+func (d *Dispatcher) autocomplete(s *discordgo.Session, i *discordgo.Interaction) (err error) {
+	data := i.ApplicationCommandData()
+	path, options := commandPath(data)
+	rt, depth := d.Router.FindFull(path...)
+	if depth != len(path) {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+
+	var focused *discordgo.ApplicationCommandInteractionDataOption
+	for _, v := range options {
+		if v.Focused {
+			focused = v
+		}
+	}
+	if focused == nil {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+
+	var a *dgrouter.Argument
+	for _, v := range rt.Arguments {
+		if v.Name == focused.Name {
+			a = v
+		}
+	}
+	if a == nil || a.Complete == nil {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+
+	// The other options are parsed where possible, since they may not be complete yet
+	ctx := NewInteractionContext(s, i, rt)
+	for _, v := range rt.Arguments {
+		for _, opt := range options {
+			if opt.Name != v.Name || opt == focused {
+				continue
+			}
+			if value, err := optionValue(ctx, v, opt, data.Resolved); err == nil {
+				ctx.Params[v.Name] = value
+			}
+		}
+	}
+
+	if d.Recover {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Value: v, Stack: debug.Stack()}
+			}
+		}()
+	}
+
+	choices, err := a.Complete(ctx, fmt.Sprint(focused.Value))
+	if err != nil {
+		return err
+	}
+	return s.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: optionChoices(choices, optionType(a)),
+		},
+	})
+}
+
+// optionChoices converts choices to application command choices within discord's limits
+// The values are converted to the type of the option, and choices that can not be converted are left out
+//    typ : type of the option being completed
+func optionChoices(choices []dgrouter.Choice, typ discordgo.ApplicationCommandOptionType) []*discordgo.ApplicationCommandOptionChoice {
+	result := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, v := range choices {
+		if len(result) == MaxChoices {
+			break
+		}
+		value, ok := choiceValue(v.Value, typ)
+		if !ok {
+			continue
+		}
+		name := v.Name
+		if utf8.RuneCountInString(name) > MaxChoiceNameLength {
+			name = string([]rune(name)[:MaxChoiceNameLength-1]) + "…"
+		}
+		result = append(result, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: value})
+	}
+	return result
+}
+
+// choiceValue converts the value of a choice to the type discord expects for an option
+// Integers are int64, numbers are float64, and everything else is a string
+func choiceValue(v interface{}, typ discordgo.ApplicationCommandOptionType) (interface{}, bool) {
+	switch typ {
+	case discordgo.ApplicationCommandOptionInteger:
+		switch n := v.(type) {
+		case int:
+			return int64(n), true
+		case int64:
+			return n, true
+		case float64:
+			return int64(n), n == math.Trunc(n) && math.Abs(n) < 1<<53
+		}
+		n, err := strconv.ParseInt(fmt.Sprint(v), 10, 64)
+		return n, err == nil
+	case discordgo.ApplicationCommandOptionNumber:
+		switch n := v.(type) {
+		case int:
+			return float64(n), true
+		case int64:
+			return float64(n), true
+		case float64:
+			return n, true
+		}
+		n, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		return n, err == nil && !math.IsNaN(n) && !math.IsInf(n, 0)
+	}
+	if str, ok := v.(string); ok {
+		return str, true
+	}
+	return fmt.Sprint(v), true
+}
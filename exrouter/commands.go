@@ -0,0 +1,284 @@
+package exrouter
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Application command limits
+const (
+	MaxCommandNameLength        = 32
+	MaxCommandDescriptionLength = 100
+	MaxCommandOptions           = 25
+)
+
+// Application command errors
+var (
+	ErrInvalidCommandName    = errors.New("name must be 1-32 lowercase letters, numbers, - or _")
+	ErrMissingDescription    = errors.New("description is required")
+	ErrDescriptionTooLong    = errors.New("description is longer than 100 characters")
+	ErrCommandTooDeep        = errors.New("subcommands can only be nested two levels deep")
+	ErrTooManyOptions        = errors.New("more than 25 options or subcommands")
+	ErrHandlerWithSubroutes  = errors.New("a command with subcommands can not have its own handler or arguments")
+	ErrRequiredAfterOptional = errors.New("required arguments must come before optional arguments")
+	ErrDuplicateOption       = errors.New("duplicate option name")
+	ErrInvalidMenuName       = errors.New("name must be 1-32 characters")
+)
+
+// commandName matches valid application command and option names
+var commandName = regexp.MustCompile(`^[-_\p{Ll}\p{Lo}\p{N}]{1,32}$`)
+
+// OptionTypes maps argument types to application command option types
+// Arguments of types that are not listed are exported as string options,
+// and their values are converted by the argument's Parse function
+var OptionTypes = map[string]discordgo.ApplicationCommandOptionType{
+	"string":  discordgo.ApplicationCommandOptionString,
+	"int":     discordgo.ApplicationCommandOptionInteger,
+	"float":   discordgo.ApplicationCommandOptionNumber,
+	"bool":    discordgo.ApplicationCommandOptionBoolean,
+	"user":    discordgo.ApplicationCommandOptionUser,
+	"member":  discordgo.ApplicationCommandOptionUser,
+	"channel": discordgo.ApplicationCommandOptionChannel,
+	"role":    discordgo.ApplicationCommandOptionRole,
+}
+
+// CommandError is returned when a route can not be represented as an application command
+type CommandError struct {
+	Route *dgrouter.Route
+
+	// Option is the name of the argument or flag that caused the error, if any
+	Option string
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	s := "/" + strings.Join(e.Route.Path(), " ")
+	if e.Option != "" {
+		s += " " + e.Option
+	}
+	return s + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// CommandErrors is a list of routes that could not be exported
+type CommandErrors []error
+
+func (e CommandErrors) Error() string {
+	var s []string
+	for _, v := range e {
+		s = append(s, v.Error())
+	}
+	return strings.Join(s, "\n")
+}
+
+// ApplicationCommands exports the routes of this router as global application commands
+// Top level routes become commands, and their subroutes become subcommand groups and subcommands.
+// Arguments and flags become options. User and message commands are exported after them.
+// Routes that can not be represented are left out and reported in a CommandErrors,
+// so the commands that were exported can still be used.
+// Routes limited to some guilds are left out, see ApplicationCommandsFor
+func (r *Route) ApplicationCommands() ([]*discordgo.ApplicationCommand, error) {
+	return r.ApplicationCommandsFor("")
+}
+
+// ApplicationCommandsFor exports the routes that can be used in a guild as application commands
+// Routes limited to other guilds and direct message only routes are left out.
+// If guildID is empty, the routes are exported as global commands,
+// and only routes that are not limited to some guilds are exported
+//    guildID : guild the commands are registered in, or empty for global commands
+func (r *Route) ApplicationCommandsFor(guildID string) ([]*discordgo.ApplicationCommand, error) {
+	var (
+		commands []*discordgo.ApplicationCommand
+		errs     CommandErrors
+	)
+
+	for _, v := range r.Routes {
+		if !v.EffectiveScope().AllowsGuild(guildID) {
+			continue
+		}
+		cmd, err := ApplicationCommand(v)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		commands = append(commands, cmd)
+	}
+
+	menus, menuErrs := contextMenuCommands(r.Route, guildID)
+	commands = append(commands, menus...)
+	errs = append(errs, menuErrs...)
+
+	if len(errs) > 0 {
+		return commands, errs
+	}
+	return commands, nil
+}
+
+// ApplicationCommand converts a top level route into an application command
+// Commands that can not be used in direct messages are exported without the DM permission
+//    route : route to convert
+func ApplicationCommand(route *dgrouter.Route) (*discordgo.ApplicationCommand, error) {
+	if err := validateRoute(route); err != nil {
+		return nil, err
+	}
+
+	options, err := commandOptions(route, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &discordgo.ApplicationCommand{
+		Type:         discordgo.ChatApplicationCommand,
+		Name:         route.Name,
+		Description:  route.Description,
+		Options:      options,
+		DMPermission: dmPermission(route.EffectiveScope()),
+	}, nil
+}
+
+// commandOptions returns the subcommands of a route,
+// or its arguments and flags if it has no subroutes
+//    depth : number of parents between the route and the top level route
+func commandOptions(route *dgrouter.Route, depth int) ([]*discordgo.ApplicationCommandOption, error) {
+	if len(route.Routes) == 0 {
+		return argumentOptions(route)
+	}
+
+	if depth >= 2 {
+		return nil, &CommandError{Route: route, Err: ErrCommandTooDeep}
+	}
+	if route.Handler != nil || len(route.Arguments) > 0 || len(route.Flags) > 0 {
+		return nil, &CommandError{Route: route, Err: ErrHandlerWithSubroutes}
+	}
+	if len(route.Routes) > MaxCommandOptions {
+		return nil, &CommandError{Route: route, Err: ErrTooManyOptions}
+	}
+
+	var options []*discordgo.ApplicationCommandOption
+	for _, v := range route.Routes {
+		if err := validateRoute(v); err != nil {
+			return nil, err
+		}
+
+		sub, err := commandOptions(v, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		typ := discordgo.ApplicationCommandOptionSubCommand
+		if len(v.Routes) > 0 {
+			typ = discordgo.ApplicationCommandOptionSubCommandGroup
+		}
+
+		options = append(options, &discordgo.ApplicationCommandOption{
+			Type:        typ,
+			Name:        v.Name,
+			Description: v.Description,
+			Options:     sub,
+		})
+	}
+	return options, nil
+}
+
+// argumentOptions converts the arguments and flags of a route into options
+// Flags are exported as optional options after the arguments
+func argumentOptions(route *dgrouter.Route) ([]*discordgo.ApplicationCommandOption, error) {
+	if len(route.Arguments)+len(route.Flags) > MaxCommandOptions {
+		return nil, &CommandError{Route: route, Err: ErrTooManyOptions}
+	}
+
+	var (
+		options  []*discordgo.ApplicationCommandOption
+		names    = map[string]bool{}
+		optional bool
+	)
+
+	add := func(name, description string, opt *discordgo.ApplicationCommandOption) error {
+		if err := validateOption(name, description); err != nil {
+			return &CommandError{route, name, err}
+		}
+		if names[name] {
+			return &CommandError{route, name, ErrDuplicateOption}
+		}
+		names[name] = true
+
+		opt.Name = name
+		opt.Description = description
+		options = append(options, opt)
+		return nil
+	}
+
+	for _, v := range route.Arguments {
+		if v.Required && optional {
+			return nil, &CommandError{route, v.Name, ErrRequiredAfterOptional}
+		}
+		optional = optional || !v.Required
+
+		typ := optionType(v)
+		err := add(v.Name, v.Description, &discordgo.ApplicationCommandOption{
+			Type:         typ,
+			Required:     v.Required,
+			Autocomplete: v.Complete != nil && autocompletes(typ),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range route.Flags {
+		typ := discordgo.ApplicationCommandOptionString
+		if v.Bool {
+			typ = discordgo.ApplicationCommandOptionBoolean
+		}
+		if err := add(v.Name, v.Description, &discordgo.ApplicationCommandOption{Type: typ}); err != nil {
+			return nil, err
+		}
+	}
+
+	return options, nil
+}
+
+// optionType returns the option type used to export an argument
+func optionType(a *dgrouter.Argument) discordgo.ApplicationCommandOptionType {
+	if t, ok := OptionTypes[a.Type]; ok && !a.Rest {
+		return t
+	}
+	return discordgo.ApplicationCommandOptionString
+}
+
+// autocompletes returns true if options of the given type can be autocompleted
+func autocompletes(typ discordgo.ApplicationCommandOptionType) bool {
+	return typ == discordgo.ApplicationCommandOptionString ||
+		typ == discordgo.ApplicationCommandOptionInteger ||
+		typ == discordgo.ApplicationCommandOptionNumber
+}
+
+// validateRoute checks the name and description of a route
+func validateRoute(route *dgrouter.Route) error {
+	if err := validateOption(route.Name, route.Description); err != nil {
+		return &CommandError{Route: route, Err: err}
+	}
+	return nil
+}
+
+// validateOption checks a command or option name and description against discord's limits
+func validateOption(name, description string) error {
+	switch {
+	case !commandName.MatchString(name):
+		return ErrInvalidCommandName
+	case description == "":
+		return ErrMissingDescription
+	case utf8.RuneCountInString(description) > MaxCommandDescriptionLength:
+		return ErrDescriptionTooLong
+	}
+	return nil
+}
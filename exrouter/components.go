@@ -0,0 +1,133 @@
+package exrouter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Route kinds used for interactions other than application commands
+const (
+	KindComponent      = "component"
+	KindModal          = "modal"
+	KindUserCommand    = "user_command"
+	KindMessageCommand = "message_command"
+)
+
+// Component errors
+var (
+	ErrNotInteraction = errors.New("context was not created by an interaction")
+	ErrNoMessage      = errors.New("interaction does not have a message")
+)
+
+// OnComponent registers a handler for buttons and select menus whose custom ID matches a pattern
+// The parameters of the pattern are stored in Context.Params as strings.
+// Context.Args holds the custom ID followed by the values chosen in a select menu
+//
+// example:
+// router.OnComponent("vote:{poll}:{option}", func(ctx *exrouter.Context) {
+//     ctx.Update("voted for ", ctx.Params.String("option"))
+// })
+//    pattern : pattern of the custom ID, see dgrouter.Pattern
+//    handler : handler function
+func (r *Route) OnComponent(pattern string, handler HandlerFunc) *Route {
+	return &Route{r.Route.OnPattern(KindComponent, pattern, WrapHandler(handler))}
+}
+
+// componentContext finds the route of a message component interaction and creates its context
+func (d *Dispatcher) componentContext(s *discordgo.Session, i *discordgo.Interaction) (*Context, error) {
+	data := i.MessageComponentData()
+	rt := d.Router.FindKind(KindComponent, data.CustomID)
+	if rt == nil {
+		return nil, dgrouter.ErrCouldNotFindRoute
+	}
+
+	ctx := NewInteractionContext(s, i, rt)
+	ctx.Args = append(Args{data.CustomID}, data.Values...)
+	ctx.Content = data.CustomID
+	patternParams(ctx, data.CustomID)
+	return ctx, nil
+}
+
+// patternParams stores the parameters of the route's pattern in the context's Params
+func patternParams(ctx *Context, value string) {
+	if ctx.Route.Pattern == nil {
+		return
+	}
+	params, _ := ctx.Route.Pattern.Match(value)
+	for k, v := range params {
+		ctx.Params[k] = v
+	}
+}
+
+// Update edits the message that the component belongs to
+func (c *Context) Update(args ...interface{}) error {
+	return c.UpdateComplex(&discordgo.InteractionResponseData{
+		Content: fmt.Sprint(args...),
+	})
+}
+
+// UpdateComplex edits the message that the component belongs to
+// The components of the message are removed if data.Components is nil
+func (c *Context) UpdateComplex(data *discordgo.InteractionResponseData) error {
+	if c.Interaction == nil {
+		return ErrNotInteraction
+	}
+
+	c.imu.Lock()
+	defer c.imu.Unlock()
+
+	if !c.responded {
+		err := c.Ses.InteractionRespond(c.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: data,
+		})
+		if err == nil {
+			c.responded = true
+		}
+		return err
+	}
+
+	if c.deferredUpdate {
+		_, err := c.Ses.InteractionResponseEdit(c.Interaction, &discordgo.WebhookEdit{
+			Content:    &data.Content,
+			Embeds:     &data.Embeds,
+			Components: &data.Components,
+		})
+		return err
+	}
+
+	if c.Interaction.Message == nil {
+		return ErrNoMessage
+	}
+	_, err := c.Ses.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         c.Interaction.Message.ID,
+		Channel:    c.Interaction.Message.ChannelID,
+		Content:    &data.Content,
+		Embeds:     &data.Embeds,
+		Components: &data.Components,
+	})
+	return err
+}
+
+// DeferUpdate acknowledges the component without changing its message yet
+// The message can be changed later with Update
+func (c *Context) DeferUpdate() error {
+	if c.Interaction == nil {
+		return ErrNotInteraction
+	}
+	return c.acknowledge(discordgo.InteractionResponseDeferredMessageUpdate)
+}
+
+// Followup sends a follow-up message for the context's interaction
+// The interaction must already have been responded to or deferred
+func (c *Context) Followup(args ...interface{}) (*discordgo.Message, error) {
+	if c.Interaction == nil {
+		return nil, ErrNotInteraction
+	}
+	return c.Ses.FollowupMessageCreate(c.Interaction, true, &discordgo.WebhookParams{
+		Content: fmt.Sprint(args...),
+	})
+}
@@ -1,10 +1,14 @@
 package exrouter
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/bind"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -19,6 +23,71 @@ type Context struct {
 	// List of arguments supplied with the command
 	Args Args
 
+	// Prefix is the prefix the command was called with
+	Prefix string
+
+	// Content is the text of the command without its prefix
+	Content string
+
+	// Tokens holds the source offsets of each argument in Content
+	// It has the same length as Args when the command is found by the router
+	Tokens []dgrouter.Token
+
+	// Params holds the arguments parsed by the route's argument schema
+	Params Params
+
+	// Flags holds the flags parsed from the arguments
+	// Flags are only parsed for routes that declare them
+	Flags Flags
+
+	// Location is the timezone used to parse times for this command
+	// If it is nil, DefaultTimezone is used
+	Location *time.Location
+
+	// Replies tracks the replies sent to this command
+	// If it is set, replying to an edited command edits the previous response
+	Replies *ReplyTracker
+
+	// replies is the number of replies sent from this context
+	replies int
+
+	// parse parses the arguments of the command once the route's middleware has run
+	// parseErr is the error it returned, see WrapHandler
+	parse    func() error
+	parseErr error
+
+	// Interaction is the interaction that called this command
+	// It is nil for message commands
+	Interaction *discordgo.Interaction
+
+	// TargetUser and TargetMember are the user a user command was used on
+	// TargetMember is nil outside of guilds
+	TargetUser   *discordgo.User
+	TargetMember *discordgo.Member
+
+	// TargetMessage is the message a message command was used on, or the message that was reacted to
+	TargetMessage *discordgo.Message
+	fetchTarget   func() *discordgo.Message
+
+	// Event is the event that called an event route, see OnEvent
+	Event interface{}
+
+	// Input is the output of the previous command of a pipeline
+	// It is also appended to the arguments of the command, see Dispatcher.MaxPipeline
+	Input string
+
+	// capture collects the replies of a command whose output is piped into another command
+	capture *strings.Builder
+
+	// deadline is cancelled when the pipeline the command is part of runs out of time
+	deadline context.Context
+
+	// imu guards the response state of the interaction and the captured replies
+	imu            sync.Mutex
+	responded      bool
+	deferred       bool
+	deferredUpdate bool
+
 	// Vars that can be optionally set using the Set and Get functions
 	vmu  sync.RWMutex
 	Vars map[string]interface{}
@@ -39,15 +108,115 @@ func (c *Context) Get(key string) interface{} {
 	return nil
 }
 
+// Bind fills the struct pointed to by v with the command's arguments
+// Fields are bound using the arg and flag struct tags, see the bind package for details
+// If the route declares flags, the flags parsed by the router are used
+// Times are interpreted in the timezone returned by Timezone
+func (c *Context) Bind(v interface{}) error {
+	var args []string
+	if len(c.Args) > 1 {
+		args = c.Args[1:]
+	}
+	if c.Flags != nil {
+		return bind.ValuesIn(args, c.Flags, c.Timezone(), v)
+	}
+	return bind.ArgsIn(args, c.Timezone(), v)
+}
+
+// RawAfter returns the text of the command starting at argument n
+// exactly as the user wrote it, including its spacing, newlines and quotes
+func (c *Context) RawAfter(n int) string {
+	if n >= 0 && n < len(c.Tokens) && len(c.Tokens) == len(c.Args) {
+		return dgrouter.RawText(c.Content, c.Tokens[n:])
+	}
+	return c.Args.After(n)
+}
+
+// Context returns a context.Context that is cancelled when the command should stop,
+// ex. when the pipeline it is part of runs out of time, see Dispatcher.PipelineTimeout
+// It is never cancelled for commands outside of pipelines
+func (c *Context) Context() context.Context {
+	if c.deadline == nil {
+		return context.Background()
+	}
+	return c.deadline
+}
+
+// Timezone returns the timezone used to parse times for this command
+func (c *Context) Timezone() *time.Location {
+	if c.Location != nil {
+		return c.Location
+	}
+	if loc := DefaultTimezone(c); loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
 // Reply replies to the sender with the given message
+// Interactions are replied to with an interaction response
+// If the command was edited, the previous reply is edited instead, see ReplyTracker
+// The replies of a command whose output is piped into another command are captured instead of sent,
+// and replies made after its pipeline has run out of time are dropped with ErrPipelineTimeout
 func (c *Context) Reply(args ...interface{}) (*discordgo.Message, error) {
-	return c.Ses.ChannelMessageSend(c.Msg.ChannelID, fmt.Sprint(args...))
+	content := fmt.Sprint(args...)
+	if c.timedOut() {
+		return nil, ErrPipelineTimeout
+	}
+	if m, ok := c.captureReply(content); ok {
+		return m, nil
+	}
+	if c.Interaction != nil {
+		return c.respond(&discordgo.InteractionResponseData{Content: content})
+	}
+	send := func() (*discordgo.Message, error) {
+		return c.Ses.ChannelMessageSend(c.Msg.ChannelID, content)
+	}
+	if c.Replies == nil {
+		return send()
+	}
+	return c.Replies.reply(c, send, func(prev *discordgo.Message) (*discordgo.Message, error) {
+		return c.editReply(prev, content, nil)
+	})
 }
 
 // ReplyEmbed replies to the sender with an embed
+// If the command was edited, the previous reply is edited instead, see ReplyTracker
 func (c *Context) ReplyEmbed(args ...interface{}) (*discordgo.Message, error) {
-	return c.Ses.ChannelMessageSendEmbed(c.Msg.ChannelID, &discordgo.MessageEmbed{
+	embed := &discordgo.MessageEmbed{
 		Description: fmt.Sprint(args...),
+	}
+	if c.timedOut() {
+		return nil, ErrPipelineTimeout
+	}
+	if m, ok := c.captureReply(embed.Description); ok {
+		return m, nil
+	}
+	if c.Interaction != nil {
+		return c.respond(&discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}})
+	}
+	send := func() (*discordgo.Message, error) {
+		return c.Ses.ChannelMessageSendEmbed(c.Msg.ChannelID, embed)
+	}
+	if c.Replies == nil {
+		return send()
+	}
+	return c.Replies.reply(c, send, func(prev *discordgo.Message) (*discordgo.Message, error) {
+		return c.editReply(prev, "", embed)
+	})
+}
+
+// editReply replaces the content and embeds of a previous reply
+func (c *Context) editReply(prev *discordgo.Message, content string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	embeds := []*discordgo.MessageEmbed{}
+	if embed != nil {
+		embeds = append(embeds, embed)
+	}
+	return c.Ses.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:      prev.ID,
+		Channel: prev.ChannelID,
+		Content: &content,
+		Embeds:  &embeds,
 	})
 }
 
@@ -78,13 +247,33 @@ func (c *Context) Member(guildID, userID string) (*discordgo.Member, error) {
 	return m, err
 }
 
+// Role retrieves a role from the state or restapi
+func (c *Context) Role(guildID, roleID string) (*discordgo.Role, error) {
+	r, err := c.Ses.State.Role(guildID, roleID)
+	if err == nil {
+		return r, nil
+	}
+
+	roles, err := c.Ses.GuildRoles(guildID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range roles {
+		if v.ID == roleID {
+			return v, nil
+		}
+	}
+	return nil, discordgo.ErrStateNotFound
+}
+
 // NewContext returns a new context from a message
 func NewContext(s *discordgo.Session, m *discordgo.Message, args Args, route *dgrouter.Route) *Context {
 	return &Context{
-		Route: route,
-		Msg:   m,
-		Ses:   s,
-		Args:  args,
-		Vars:  map[string]interface{}{},
+		Route:  route,
+		Msg:    m,
+		Ses:    s,
+		Args:   args,
+		Params: Params{},
+		Vars:   map[string]interface{}{},
 	}
 }
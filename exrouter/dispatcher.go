@@ -0,0 +1,269 @@
+package exrouter
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// DMMode controls how commands sent in direct messages are handled
+type DMMode int
+
+// Direct message modes
+const (
+	// DMAllow handles direct messages the same as guild messages
+	DMAllow DMMode = iota
+
+	// DMIgnore ignores commands sent in direct messages
+	DMIgnore
+
+	// DMNoPrefix allows commands in direct messages to be used without a prefix
+	DMNoPrefix
+)
+
+// ErrorFunc is called when a command fails to dispatch
+type ErrorFunc func(s *discordgo.Session, m *discordgo.Message, err error)
+
+// PanicError is returned when a handler panics and the dispatcher recovers it
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprint("handler panicked: ", e.Value)
+}
+
+// Dispatcher routes messages to a router using options configured once
+//
+// example:
+// d := exrouter.NewDispatcher(router, exrouter.StaticPrefix("!"))
+// s.AddHandler(d.Handle)
+//
+// // Optionally call commands again when they are edited
+// d.Replies = exrouter.NewReplyTracker(time.Minute * 5)
+// s.AddHandler(d.HandleUpdate)
+type Dispatcher struct {
+	Router *Route
+
+	// Prefixes returns the prefixes a message may use
+	Prefixes PrefixResolver
+
+	// BotID is the user ID of the bot
+	// If it is empty, the ID of the session's user is used
+	BotID string
+
+	// IgnoreBots ignores messages sent by bots
+	// Routes can still allow bots with dgrouter.Route.Bots
+	IgnoreBots bool
+
+	// IgnoreSelf ignores messages sent by the bot itself
+	IgnoreSelf bool
+
+	// IgnoreWebhooks ignores messages sent by webhooks
+	IgnoreWebhooks bool
+
+	// IgnoreSystem ignores system messages, such as member join messages
+	IgnoreSystem bool
+
+	// DirectMessages controls how commands sent in direct messages are handled
+	DirectMessages DMMode
+
+	// MentionPrefix allows a mention of the bot to be used as a prefix
+	// A message containing only a mention calls the router's Default route
+	MentionPrefix bool
+
+	// Normalize is called on the content of a message before it is routed, ex. NormalizeSpace
+	Normalize func(content string) string
+
+	// OnError is called with any error that occurs while dispatching a command,
+	// except for dgrouter.ErrCouldNotFindRoute
+	OnError ErrorFunc
+
+	// Recover recovers panics in handlers and reports them as a *PanicError
+	Recover bool
+
+	// Replies tracks the replies sent to commands
+	// It must be set for HandleUpdate to call edited commands again
+	Replies *ReplyTracker
+
+	// MaxPipeline is the maximum number of commands in a pipeline, ex. !search cats | random | say
+	// The output of each command is passed to the next, which must allow it with dgrouter.Route.Pipe.
+	// A message is only a pipeline if every command after a pipe separator exists and one of them
+	// accepts piped input, so commands such as !poll yes | no still receive the separator as an argument.
+	// Pipelines are disabled if it is less than 2. Pipeline errors are sent to the sender and passed to OnError
+	MaxPipeline int
+
+	// PipelineTimeout limits the time a pipeline can run for
+	// Once it runs out, the pipeline stops with ErrPipelineTimeout and the Context.Context of the
+	// running command is cancelled. Handlers are not interrupted, so long running commands should watch it.
+	// Replies made after the pipeline ran out of time are dropped
+	PipelineTimeout time.Duration
+
+	// OnReject is called when a command is used outside of its route's scope,
+	// ex. a guild only command used in direct messages. See dgrouter.Route.GuildOnly
+	OnReject RejectFunc
+}
+
+// NewDispatcher returns a dispatcher with the recommended options
+// Messages from bots, webhooks, the system and the bot itself are ignored,
+// bot mentions can be used as a prefix, panics are recovered
+// and commands used outside of their scope are answered with the reason
+//    router   : router to dispatch messages to
+//    prefixes : resolver that returns the prefixes a message may use
+func NewDispatcher(router *Route, prefixes PrefixResolver) *Dispatcher {
+	return &Dispatcher{
+		Router:         router,
+		Prefixes:       prefixes,
+		IgnoreBots:     true,
+		IgnoreSelf:     true,
+		IgnoreWebhooks: true,
+		IgnoreSystem:   true,
+		MentionPrefix:  true,
+		Recover:        true,
+		OnReject:       ReplyRejection,
+	}
+}
+
+// Handle dispatches a MessageCreate event
+// It can be passed directly to session.AddHandler
+func (d *Dispatcher) Handle(s *discordgo.Session, m *discordgo.MessageCreate) {
+	d.Dispatch(s, m.Message)
+}
+
+// HandleUpdate dispatches a MessageUpdate event
+// Commands edited within the window of the dispatcher's ReplyTracker are called again,
+// and their replies edit the responses to the original command
+// It can be passed directly to session.AddHandler
+func (d *Dispatcher) HandleUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	if d.Replies == nil || m.Message == nil || m.Author == nil || m.EditedTimestamp == nil {
+		return
+	}
+	if time.Since(m.Timestamp) > d.Replies.Window {
+		return
+	}
+	d.Dispatch(s, m.Message)
+}
+
+// Dispatch finds and executes the route for a message
+// Errors other than dgrouter.ErrCouldNotFindRoute are also passed to OnError
+// Once the command returns, replies to an earlier run of it that were not edited are deleted, see ReplyTracker
+func (d *Dispatcher) Dispatch(s *discordgo.Session, m *discordgo.Message) error {
+	if d.Replies != nil {
+		defer d.Replies.finish(s, m.ID)
+	}
+	err := d.dispatch(s, m)
+	if err != nil && err != dgrouter.ErrCouldNotFindRoute && d.OnError != nil {
+		d.OnError(s, m, err)
+	}
+	return err
+}
+
+func (d *Dispatcher) dispatch(s *discordgo.Session, m *discordgo.Message) error {
+	res, err := d.Resolve(s, m)
+	lerr, unclosed := err.(*dgrouter.LexError)
+	if err != nil && !unclosed {
+		return err
+	}
+
+	ctx := res.Context(s, m)
+	ctx.Replies = d.Replies
+
+	if err := d.checkScope(ctx); err != nil {
+		return err
+	}
+
+	// A message containing only a mention calls the default route without arguments
+	if len(res.Path) == 0 {
+		return d.execute(res.Route, ctx)
+	}
+
+	// Unclosed quotes are reported with the route's usage once its middleware has run
+	if unclosed {
+		ctx.parse = func() error { return usageError(ctx, lerr) }
+		return d.execute(res.Route, ctx)
+	}
+
+	if d.MaxPipeline > 1 {
+		if stages := pipelineStages(res.Content); stages != nil {
+			resolved, err := d.resolvePipeline(m, stages)
+			if err != nil {
+				return pipelineError(ctx, err)
+			}
+			if resolved != nil {
+				return pipelineError(ctx, d.pipeline(s, m, res.Prefix, stages, resolved))
+			}
+		}
+	}
+
+	ctx.parse = func() error { return parseParams(ctx) }
+
+	return d.execute(res.Route, ctx)
+}
+
+// execute calls the route's handler, recovering panics if enabled
+// Errors from parsing the context's arguments are returned after the handler chain has run
+func (d *Dispatcher) execute(rt *dgrouter.Route, ctx *Context) (err error) {
+	if rt.Handler == nil {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+
+	if d.Recover {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{Value: v, Stack: debug.Stack()}
+			}
+		}()
+	}
+
+	rt.Handler(ctx)
+	return ctx.parseErr
+}
+
+// ignored returns true if the message is from a source the dispatcher ignores
+func (d *Dispatcher) ignored(m *discordgo.Message, botID string) bool {
+	switch {
+	case d.IgnoreWebhooks && m.WebhookID != "":
+		return true
+	case d.IgnoreSystem && isSystemMessage(m):
+		return true
+	case d.IgnoreSelf && botID != "" && m.Author != nil && m.Author.ID == botID:
+		return true
+	}
+	return false
+}
+
+// isSystemMessage returns true if the message was sent by discord rather than a user
+func isSystemMessage(m *discordgo.Message) bool {
+	if m.Author != nil && m.Author.System {
+		return true
+	}
+	return m.Type != discordgo.MessageTypeDefault && m.Type != discordgo.MessageTypeReply
+}
+
+// botID returns the configured bot ID or the ID of the session's user
+func (d *Dispatcher) botID(s *discordgo.Session) string {
+	if d.BotID != "" {
+		return d.BotID
+	}
+	if s != nil && s.State != nil && s.State.User != nil {
+		return s.State.User.ID
+	}
+	return ""
+}
+
+// NormalizeSpace trims the whitespace surrounding a message
+// and replaces non-breaking and zero width spaces that some clients insert
+func NormalizeSpace(content string) string {
+	content = strings.NewReplacer(
+		"\u00a0", " ",
+		"\u200b", "",
+		"\ufeff", "",
+		"\r\n", "\n",
+	).Replace(content)
+	return strings.TrimSpace(content)
+}
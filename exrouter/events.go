@@ -0,0 +1,229 @@
+package exrouter
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// KindEvent is the route kind used for gateway and custom events
+const KindEvent = "event"
+
+// contextType is the type of the first parameter of event handlers
+var contextType = reflect.TypeOf(&Context{})
+
+// eventTypes maps the names of dispatched events to their types,
+// so routes for interfaces can check which events implement them
+var eventTypes sync.Map
+
+// Tick is an event dispatched periodically by Dispatcher.Tick
+type Tick struct {
+	// Name is the name given to the ticker
+	Name string
+	Time time.Time
+}
+
+// OnEvent registers a handler for an event, such as *discordgo.GuildMemberAdd
+// The handler must be a function that takes a *Context and a pointer to the event,
+// and is called for every event of that type. If the event parameter is an interface,
+// the handler is called for every event that implements it. The event is also stored in Context.Event.
+// Events can be narrowed down further with filters, see dgrouter.Route.Filter
+//
+// example:
+// router.OnEvent(func(ctx *exrouter.Context, m *discordgo.GuildMemberAdd) {
+//     ctx.Ses.ChannelMessageSend(welcomeChannel, "welcome "+m.User.Mention())
+// }).Filter(exrouter.InGuild(guildID))
+//    handler : func(*exrouter.Context, *discordgo.EventType)
+func (r *Route) OnEvent(handler interface{}) *Route {
+	fn := reflect.ValueOf(handler)
+	t := fn.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.NumOut() != 0 || t.In(0) != contextType {
+		panic("exrouter: OnEvent handler must be a func(*exrouter.Context, event)")
+	}
+
+	evt := t.In(1)
+	matcher := exactMatcher(evt.String())
+	if evt.Kind() == reflect.Interface {
+		matcher = func(name string) bool {
+			t, ok := eventTypes.Load(name)
+			return ok && t.(reflect.Type).Implements(evt)
+		}
+	}
+
+	return &Route{r.Route.AddEvent(KindEvent, evt.String(), matcher, WrapHandler(func(ctx *Context) {
+		fn.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(ctx.Event)})
+	}))}
+}
+
+// InGuild returns a filter that only allows events from the given guilds
+func InGuild(guildIDs ...string) dgrouter.FilterFunc {
+	return Filter(func(ctx *Context) bool {
+		for _, v := range guildIDs {
+			if ctx.Msg.GuildID == v {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HandleEvent dispatches any event
+// It can be passed directly to session.AddHandler to receive every gateway event
+func (d *Dispatcher) HandleEvent(s *discordgo.Session, e interface{}) {
+	d.DispatchEvent(s, e)
+}
+
+// DispatchEvent calls every event route registered for the type of e
+// Context.Msg describes the guild, channel and user of the event where possible,
+// so the same middleware can be used as for commands
+//    e : gateway event, ex. *discordgo.VoiceStateUpdate, or a custom event
+func (d *Dispatcher) DispatchEvent(s *discordgo.Session, e interface{}) error {
+	err := d.dispatchEvent(s, e)
+	if err != nil && err != dgrouter.ErrCouldNotFindRoute && d.OnError != nil {
+		d.OnError(s, eventMessage(e), err)
+	}
+	return err
+}
+
+func (d *Dispatcher) dispatchEvent(s *discordgo.Session, e interface{}) error {
+	if e == nil {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+
+	t := reflect.TypeOf(e)
+	name := t.String()
+	eventTypes.LoadOrStore(name, t)
+
+	var (
+		called   bool
+		firstErr error
+	)
+	for _, rt := range d.Router.FindAllKind(KindEvent, name) {
+		ctx := NewContext(s, eventMessage(e), Args{name}, rt)
+		ctx.Event = e
+		if !rt.Allows(ctx) {
+			continue
+		}
+
+		called = true
+		if err := d.execute(rt, ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if !called {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+	return firstErr
+}
+
+// Tick dispatches a Tick event with the given name every interval until stop is called
+// It can be used to run periodic tasks through the router, ex. removing expired roles
+//    s        : session passed to the handlers
+//    name     : name of the ticker, stored in Tick.Name
+//    interval : time between ticks
+func (d *Dispatcher) Tick(s *discordgo.Session, name string, interval time.Duration) (stop func()) {
+	t := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case now := <-t.C:
+				d.DispatchEvent(s, &Tick{Name: name, Time: now})
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		t.Stop()
+		close(done)
+	}
+}
+
+// eventMessage returns a message describing the guild, channel and user of an event
+func eventMessage(e interface{}) *discordgo.Message {
+	m := &discordgo.Message{}
+
+	member := func(v *discordgo.Member) {
+		if v != nil {
+			m.GuildID = v.GuildID
+			m.Member = v
+			m.Author = v.User
+		}
+	}
+	channel := func(v *discordgo.Channel) {
+		if v != nil {
+			m.GuildID, m.ChannelID = v.GuildID, v.ID
+		}
+	}
+
+	switch v := e.(type) {
+	case *discordgo.MessageCreate:
+		if v.Message != nil {
+			return v.Message
+		}
+	case *discordgo.MessageUpdate:
+		if v.Message != nil {
+			return v.Message
+		}
+	case *discordgo.MessageDelete:
+		if v.Message != nil {
+			return v.Message
+		}
+	case *discordgo.GuildMemberAdd:
+		member(v.Member)
+	case *discordgo.GuildMemberRemove:
+		member(v.Member)
+	case *discordgo.GuildMemberUpdate:
+		member(v.Member)
+	case *discordgo.GuildBanAdd:
+		m.GuildID, m.Author = v.GuildID, v.User
+	case *discordgo.GuildBanRemove:
+		m.GuildID, m.Author = v.GuildID, v.User
+	case *discordgo.VoiceStateUpdate:
+		if v.VoiceState != nil {
+			member(v.Member)
+			m.GuildID, m.ChannelID = v.GuildID, v.ChannelID
+			if m.Author == nil {
+				m.Author = &discordgo.User{ID: v.UserID}
+			}
+		}
+	case *discordgo.ChannelCreate:
+		channel(v.Channel)
+	case *discordgo.ChannelUpdate:
+		channel(v.Channel)
+	case *discordgo.ChannelDelete:
+		channel(v.Channel)
+	case *discordgo.GuildCreate:
+		if v.Guild != nil {
+			m.GuildID = v.ID
+		}
+	case *discordgo.GuildDelete:
+		if v.Guild != nil {
+			m.GuildID = v.ID
+		}
+	case *discordgo.TypingStart:
+		m.GuildID, m.ChannelID = v.GuildID, v.ChannelID
+		m.Author = &discordgo.User{ID: v.UserID}
+	case *discordgo.MessageReactionAdd:
+		if v.MessageReaction != nil {
+			return reactionMessage(v.MessageReaction, v.Member)
+		}
+	case *discordgo.MessageReactionRemove:
+		if v.MessageReaction != nil {
+			return reactionMessage(v.MessageReaction, nil)
+		}
+	case *discordgo.InteractionCreate:
+		if v.Interaction != nil {
+			return interactionMessage(v.Interaction)
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,78 @@
+package exrouter
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/timeparse"
+)
+
+// Flags holds the flags parsed from command arguments
+// Each flag maps to every value it was given, in order
+type Flags map[string][]string
+
+// ParseFlags separates flags from command arguments
+// See dgrouter.ParseFlags for the accepted syntax
+//    args  : arguments to parse, not including the command name
+//    flags : flags that are accepted. If empty, any flag of the form --name or --name=value is accepted
+func ParseFlags(args Args, flags []*dgrouter.Flag) (Args, Flags, error) {
+	positional, values, err := dgrouter.ParseFlags(args, flags)
+	return positional, values, err
+}
+
+// Has returns true if the flag was supplied
+func (f Flags) Has(name string) bool {
+	_, ok := f[name]
+	return ok
+}
+
+// String returns the last value given to a flag
+func (f Flags) String(name string) string {
+	if v := f[name]; len(v) > 0 {
+		return v[len(v)-1]
+	}
+	return ""
+}
+
+// Strings returns every value given to a flag
+func (f Flags) Strings(name string) []string {
+	return f[name]
+}
+
+// Int returns the value of a flag as an integer
+func (f Flags) Int(name string) int {
+	n, _ := strconv.Atoi(f.String(name))
+	return n
+}
+
+// Float returns the value of a flag as a floating point number
+func (f Flags) Float(name string) float64 {
+	n, _ := strconv.ParseFloat(f.String(name), 64)
+	return n
+}
+
+// Bool returns true if a boolean flag was supplied
+func (f Flags) Bool(name string) bool {
+	if !f.Has(name) {
+		return false
+	}
+	b, err := strconv.ParseBool(f.String(name))
+	return err == nil && b
+}
+
+// Duration returns the value of a flag as a duration, ex. --for 2d or --for "3 hours"
+// See timeparse.ParseDuration for the accepted formats
+func (f Flags) Duration(name string) time.Duration {
+	d, _ := timeparse.ParseDuration(f.String(name))
+	return d
+}
+
+// Time returns the value of a flag as a point in time, ex. --at "tomorrow 9am"
+// See timeparse.ParseTime for the accepted formats
+//    name : name of the flag
+//    loc  : timezone of the user, see Context.Timezone
+func (f Flags) Time(name string, loc *time.Location) time.Time {
+	t, _ := timeparse.ParseTime(f.String(name), time.Now(), loc)
+	return t
+}
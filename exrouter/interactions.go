@@ -0,0 +1,303 @@
+package exrouter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleInteraction dispatches an InteractionCreate event
+// It can be passed directly to session.AddHandler
+func (d *Dispatcher) HandleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	d.DispatchInteraction(s, i.Interaction)
+}
+
+// DispatchInteraction finds and executes the route for an interaction
+// Application commands are routed by their command and subcommand names, and their
+// options are converted using the route's argument schema and flags.
+// Message components and modals are routed by their custom ID, see Route.OnComponent and Route.OnModal.
+// Autocomplete interactions are answered by the Complete function of the argument being typed.
+// User and message commands are routed by their name, see Route.OnUserCommand and Route.OnMessageCommand
+// Handlers are called through the same middleware as message commands.
+// Errors other than dgrouter.ErrCouldNotFindRoute are also passed to OnError
+func (d *Dispatcher) DispatchInteraction(s *discordgo.Session, i *discordgo.Interaction) error {
+	err := d.dispatchInteraction(s, i)
+	if err != nil && err != dgrouter.ErrCouldNotFindRoute && d.OnError != nil {
+		d.OnError(s, interactionMessage(i), err)
+	}
+	return err
+}
+
+func (d *Dispatcher) dispatchInteraction(s *discordgo.Session, i *discordgo.Interaction) error {
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		return d.autocomplete(s, i)
+	}
+
+	ctx, err := d.interactionContext(s, i)
+	if err != nil {
+		return err
+	}
+	if err := d.checkScope(ctx); err != nil {
+		return err
+	}
+	return d.execute(ctx.Route, ctx)
+}
+
+// interactionContext finds the route of an interaction and creates its context
+func (d *Dispatcher) interactionContext(s *discordgo.Session, i *discordgo.Interaction) (*Context, error) {
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		return d.componentContext(s, i)
+
+	case discordgo.InteractionModalSubmit:
+		return d.modalContext(s, i)
+
+	case discordgo.InteractionApplicationCommand:
+		data := i.ApplicationCommandData()
+		if data.CommandType == discordgo.UserApplicationCommand || data.CommandType == discordgo.MessageApplicationCommand {
+			return d.contextMenuContext(s, i, data)
+		}
+		return d.commandContext(s, i, data)
+	}
+
+	return nil, dgrouter.ErrCouldNotFindRoute
+}
+
+// commandContext finds the route of an application command and creates its context
+func (d *Dispatcher) commandContext(s *discordgo.Session, i *discordgo.Interaction, data discordgo.ApplicationCommandInteractionData) (*Context, error) {
+	path, options := commandPath(data)
+	rt, depth := d.Router.FindFull(path...)
+	if depth != len(path) {
+		return nil, dgrouter.ErrCouldNotFindRoute
+	}
+
+	ctx := NewInteractionContext(s, i, rt)
+	ctx.Args = Args{strings.Join(path, string(separator))}
+	ctx.Content = ctx.Args[0]
+	ctx.parse = func() error {
+		if err := parseOptions(ctx, options, data.Resolved); err != nil {
+			return usageError(ctx, err)
+		}
+		ctx.Content = strings.Join(ctx.Args, string(separator))
+		return nil
+	}
+	return ctx, nil
+}
+
+// commandPath returns the names of the command and subcommands of an interaction
+// and the options supplied to the deepest subcommand
+func commandPath(data discordgo.ApplicationCommandInteractionData) ([]string, []*discordgo.ApplicationCommandInteractionDataOption) {
+	path := []string{data.Name}
+	options := data.Options
+	for len(options) == 1 && (options[0].Type == discordgo.ApplicationCommandOptionSubCommand ||
+		options[0].Type == discordgo.ApplicationCommandOptionSubCommandGroup) {
+		path = append(path, options[0].Name)
+		options = options[0].Options
+	}
+	return path, options
+}
+
+// parseOptions converts interaction options into the context's Args, Params and Flags
+// Options are matched to the route's arguments and flags by name
+// Omitted optional arguments that are followed by other arguments are left empty in Args,
+// so every argument is at the position the route's schema gives it
+func parseOptions(ctx *Context, options []*discordgo.ApplicationCommandInteractionDataOption, resolved *discordgo.ApplicationCommandInteractionDataResolved) error {
+	byName := map[string]*discordgo.ApplicationCommandInteractionDataOption{}
+	for _, v := range options {
+		byName[v.Name] = v
+	}
+
+	var missing int
+	for _, a := range ctx.Route.Arguments {
+		opt, ok := byName[a.Name]
+		if !ok {
+			if a.Required {
+				return &dgrouter.ArgumentError{Route: ctx.Route, Argument: a, Err: dgrouter.ErrMissingArgument}
+			}
+			missing++
+			continue
+		}
+
+		v, err := optionValue(ctx, a, opt, resolved)
+		if err != nil {
+			return &dgrouter.ArgumentError{Route: ctx.Route, Argument: a, Err: err}
+		}
+		ctx.Params[a.Name] = v
+		for ; missing > 0; missing-- {
+			ctx.Args = append(ctx.Args, "")
+		}
+		ctx.Args = append(ctx.Args, optionString(opt))
+	}
+
+	if len(ctx.Route.Flags) > 0 {
+		ctx.Flags = Flags{}
+		for _, f := range ctx.Route.Flags {
+			if opt, ok := byName[f.Name]; ok {
+				ctx.Flags[f.Name] = []string{optionString(opt)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// optionValue converts an option into the value of an argument
+// Users, members, channels and roles are taken from the resolved data of the interaction when possible,
+// other options are converted with the argument's Parse function, so its validation also applies to slash commands.
+// Integers, numbers and booleans are used as they are if the argument has no Parse function
+func optionValue(ctx *Context, a *dgrouter.Argument, opt *discordgo.ApplicationCommandInteractionDataOption, resolved *discordgo.ApplicationCommandInteractionDataResolved) (interface{}, error) {
+	if a.Parse == nil {
+		switch opt.Type {
+		case discordgo.ApplicationCommandOptionInteger:
+			return int(opt.IntValue()), nil
+		case discordgo.ApplicationCommandOptionNumber:
+			return opt.FloatValue(), nil
+		case discordgo.ApplicationCommandOptionBoolean:
+			return opt.BoolValue(), nil
+		}
+	}
+
+	if resolved != nil {
+		id, _ := opt.Value.(string)
+		switch opt.Type {
+		case discordgo.ApplicationCommandOptionUser:
+			if a.Type == "member" {
+				if m, ok := resolved.Members[id]; ok {
+					m.User = resolved.Users[id]
+					m.GuildID = ctx.Msg.GuildID
+					return m, nil
+				}
+				break
+			}
+			if u, ok := resolved.Users[id]; ok {
+				return u, nil
+			}
+		case discordgo.ApplicationCommandOptionChannel:
+			if ch, ok := resolved.Channels[id]; ok {
+				return ch, nil
+			}
+		case discordgo.ApplicationCommandOptionRole:
+			if r, ok := resolved.Roles[id]; ok {
+				return r, nil
+			}
+		}
+	}
+
+	raw := optionString(opt)
+	if a.Parse == nil {
+		return raw, nil
+	}
+	return a.Parse(ctx, raw)
+}
+
+// optionString returns the text form of an option, as it would be written in a message command
+func optionString(opt *discordgo.ApplicationCommandInteractionDataOption) string {
+	switch opt.Type {
+	case discordgo.ApplicationCommandOptionInteger:
+		return strconv.FormatInt(opt.IntValue(), 10)
+	case discordgo.ApplicationCommandOptionUser:
+		return fmt.Sprint("<@", opt.Value, ">")
+	case discordgo.ApplicationCommandOptionChannel:
+		return fmt.Sprint("<#", opt.Value, ">")
+	case discordgo.ApplicationCommandOptionRole:
+		return fmt.Sprint("<@&", opt.Value, ">")
+	}
+	return fmt.Sprint(opt.Value)
+}
+
+// interactionUser returns the user that created an interaction
+func interactionUser(i *discordgo.Interaction) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// interactionMessage returns a message describing where an interaction was created
+// It lets handlers that use Context.Msg work with interactions
+func interactionMessage(i *discordgo.Interaction) *discordgo.Message {
+	m := &discordgo.Message{
+		ID:        i.ID,
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		Author:    interactionUser(i),
+		Member:    i.Member,
+	}
+	if m.Member != nil {
+		m.Member.GuildID = i.GuildID
+	}
+	return m
+}
+
+// NewInteractionContext returns a new context from an interaction
+// Context.Msg describes the channel, guild and user of the interaction
+func NewInteractionContext(s *discordgo.Session, i *discordgo.Interaction, route *dgrouter.Route) *Context {
+	ctx := NewContext(s, interactionMessage(i), nil, route)
+	ctx.Interaction = i
+	ctx.Prefix = "/"
+	return ctx
+}
+
+// respond sends a response to the context's interaction
+// The first response replies to the interaction, or fills in the deferred response if Defer was called.
+// Later responses are sent as follow-up messages
+func (c *Context) respond(data *discordgo.InteractionResponseData) (*discordgo.Message, error) {
+	c.imu.Lock()
+	defer c.imu.Unlock()
+
+	switch {
+	case c.deferred:
+		c.deferred = false
+		return c.Ses.InteractionResponseEdit(c.Interaction, &discordgo.WebhookEdit{
+			Content:    &data.Content,
+			Embeds:     &data.Embeds,
+			Components: &data.Components,
+		})
+
+	case c.responded:
+		return c.Ses.FollowupMessageCreate(c.Interaction, true, &discordgo.WebhookParams{
+			Content:    data.Content,
+			Embeds:     data.Embeds,
+			Components: data.Components,
+			Flags:      data.Flags,
+		})
+	}
+
+	err := c.Ses.InteractionRespond(c.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.responded = true
+	return c.Ses.InteractionResponse(c.Interaction)
+}
+
+// Defer acknowledges the context's interaction without replying yet
+// Discord shows a loading state until the next reply, which must be sent within 15 minutes
+// It does nothing for message commands or interactions that were already responded to
+func (c *Context) Defer() error {
+	return c.acknowledge(discordgo.InteractionResponseDeferredChannelMessageWithSource)
+}
+
+// acknowledge sends an initial response without any data
+func (c *Context) acknowledge(typ discordgo.InteractionResponseType) error {
+	c.imu.Lock()
+	defer c.imu.Unlock()
+
+	if c.Interaction == nil || c.responded {
+		return nil
+	}
+	err := c.Ses.InteractionRespond(c.Interaction, &discordgo.InteractionResponse{Type: typ})
+	if err != nil {
+		return err
+	}
+	c.responded = true
+	c.deferred = typ == discordgo.InteractionResponseDeferredChannelMessageWithSource
+	c.deferredUpdate = typ == discordgo.InteractionResponseDeferredMessageUpdate
+	return nil
+}
@@ -0,0 +1,100 @@
+package exrouter
+
+import (
+	"unicode/utf8"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// OnUserCommand registers a handler for a user command,
+// which is used from the Apps menu when right clicking a user
+// The user is stored in Context.TargetUser, and in Context.TargetMember in guilds
+//    name    : name of the command shown in the menu
+//    handler : handler function
+func (r *Route) OnUserCommand(name string, handler HandlerFunc) *Route {
+	return &Route{r.Route.OnKind(KindUserCommand, name, exactMatcher(name), WrapHandler(handler))}
+}
+
+// OnMessageCommand registers a handler for a message command,
+// which is used from the Apps menu when right clicking a message
+// The message is stored in Context.TargetMessage
+//    name    : name of the command shown in the menu
+//    handler : handler function
+func (r *Route) OnMessageCommand(name string, handler HandlerFunc) *Route {
+	return &Route{r.Route.OnKind(KindMessageCommand, name, exactMatcher(name), WrapHandler(handler))}
+}
+
+// exactMatcher returns a matcher that only matches name
+func exactMatcher(name string) func(string) bool {
+	return func(s string) bool {
+		return s == name
+	}
+}
+
+// contextMenuContext finds the route of a user or message command and creates its context
+func (d *Dispatcher) contextMenuContext(s *discordgo.Session, i *discordgo.Interaction, data discordgo.ApplicationCommandInteractionData) (*Context, error) {
+	kind := KindUserCommand
+	if data.CommandType == discordgo.MessageApplicationCommand {
+		kind = KindMessageCommand
+	}
+
+	rt := d.Router.FindKind(kind, data.Name)
+	if rt == nil {
+		return nil, dgrouter.ErrCouldNotFindRoute
+	}
+
+	ctx := NewInteractionContext(s, i, rt)
+	ctx.Args = Args{data.Name, data.TargetID}
+	ctx.Content = data.Name
+
+	if data.Resolved != nil {
+		if kind == KindMessageCommand {
+			ctx.TargetMessage = data.Resolved.Messages[data.TargetID]
+		} else {
+			ctx.TargetUser = data.Resolved.Users[data.TargetID]
+			if m, ok := data.Resolved.Members[data.TargetID]; ok {
+				m.User = ctx.TargetUser
+				m.GuildID = i.GuildID
+				ctx.TargetMember = m
+			}
+		}
+	}
+
+	return ctx, nil
+}
+
+// contextMenuCommands exports the user and message commands of a router that can be used in a guild
+//    guildID : see ApplicationCommandsFor
+func contextMenuCommands(r *dgrouter.Route, guildID string) ([]*discordgo.ApplicationCommand, CommandErrors) {
+	var (
+		commands []*discordgo.ApplicationCommand
+		errs     CommandErrors
+	)
+
+	for _, v := range r.Events {
+		typ := discordgo.UserApplicationCommand
+		switch v.Kind {
+		case KindUserCommand:
+		case KindMessageCommand:
+			typ = discordgo.MessageApplicationCommand
+		default:
+			continue
+		}
+		if !v.EffectiveScope().AllowsGuild(guildID) {
+			continue
+		}
+
+		if n := utf8.RuneCountInString(v.Name); n == 0 || n > MaxCommandNameLength {
+			errs = append(errs, &CommandError{Route: v, Err: ErrInvalidMenuName})
+			continue
+		}
+		commands = append(commands, &discordgo.ApplicationCommand{
+			Type:         typ,
+			Name:         v.Name,
+			DMPermission: dmPermission(v.EffectiveScope()),
+		})
+	}
+
+	return commands, errs
+}
@@ -0,0 +1,133 @@
+package exrouter
+
+import (
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// OnModal registers a handler for submitted modals whose custom ID matches a pattern
+// The values of the modal's text inputs are stored in Context.Params by their custom ID.
+// Inputs named by the route's argument schema are converted by their argument,
+// so the same typed accessors can be used as for commands. Other inputs are stored as strings.
+// The parameters of the pattern are also stored in Context.Params.
+//
+// example:
+// router.OnModal("appeal:{case}", func(ctx *exrouter.Context) {
+//     ctx.Reply("appeal received for ", ctx.Params.String("case"), ": ", ctx.Params.String("reason"))
+// }).Args(arg.Rest("reason"), arg.Duration("since").Optional())
+//    pattern : pattern of the custom ID, see dgrouter.Pattern
+//    handler : handler function
+func (r *Route) OnModal(pattern string, handler HandlerFunc) *Route {
+	return &Route{r.Route.OnPattern(KindModal, pattern, WrapHandler(handler))}
+}
+
+// OpenModal responds to the context's interaction by opening a modal
+// Each text input is placed in its own row.
+// Modals can not be opened in response to a submitted modal, so forms with several steps
+// should open each step from a button, ex. with a custom ID like appeal:{step}
+//    customID : custom ID used to route the submitted modal, see Route.OnModal
+//    title    : title of the modal
+//    inputs   : text inputs of the modal
+func (c *Context) OpenModal(customID, title string, inputs ...discordgo.TextInput) error {
+	if c.Interaction == nil {
+		return ErrNotInteraction
+	}
+
+	var rows []discordgo.MessageComponent
+	for _, v := range inputs {
+		rows = append(rows, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{v},
+		})
+	}
+
+	c.imu.Lock()
+	defer c.imu.Unlock()
+
+	err := c.Ses.InteractionRespond(c.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   customID,
+			Title:      title,
+			Components: rows,
+		},
+	})
+	if err == nil {
+		c.responded = true
+	}
+	return err
+}
+
+// modalContext finds the route of a submitted modal and creates its context
+func (d *Dispatcher) modalContext(s *discordgo.Session, i *discordgo.Interaction) (*Context, error) {
+	data := i.ModalSubmitData()
+	rt := d.Router.FindKind(KindModal, data.CustomID)
+	if rt == nil {
+		return nil, dgrouter.ErrCouldNotFindRoute
+	}
+
+	ctx := NewInteractionContext(s, i, rt)
+	ctx.Args = Args{data.CustomID}
+	ctx.Content = data.CustomID
+
+	fields := map[string]string{}
+	for _, v := range textInputs(data.Components) {
+		fields[v.CustomID] = v.Value
+		ctx.Args = append(ctx.Args, v.Value)
+	}
+	ctx.parse = func() error {
+		if err := parseFields(ctx, fields); err != nil {
+			return usageError(ctx, err)
+		}
+		patternParams(ctx, data.CustomID)
+		return nil
+	}
+	return ctx, nil
+}
+
+// parseFields stores the values of a modal's text inputs in the context's Params
+// Empty inputs are treated as if they were not supplied
+func parseFields(ctx *Context, fields map[string]string) error {
+	for k, v := range fields {
+		if v != "" {
+			ctx.Params[k] = v
+		}
+	}
+
+	for _, a := range ctx.Route.Arguments {
+		raw := fields[a.Name]
+		if raw == "" {
+			if a.Required {
+				return &dgrouter.ArgumentError{Route: ctx.Route, Argument: a, Err: dgrouter.ErrMissingArgument}
+			}
+			continue
+		}
+		if a.Parse == nil {
+			continue
+		}
+
+		v, err := a.Parse(ctx, raw)
+		if err != nil {
+			return &dgrouter.ArgumentError{Route: ctx.Route, Argument: a, Err: err}
+		}
+		ctx.Params[a.Name] = v
+	}
+	return nil
+}
+
+// textInputs returns the text inputs of a modal in the order they are displayed
+func textInputs(components []discordgo.MessageComponent) []discordgo.TextInput {
+	var inputs []discordgo.TextInput
+	for _, v := range components {
+		switch c := v.(type) {
+		case *discordgo.ActionsRow:
+			inputs = append(inputs, textInputs(c.Components)...)
+		case discordgo.ActionsRow:
+			inputs = append(inputs, textInputs(c.Components)...)
+		case *discordgo.TextInput:
+			inputs = append(inputs, *c)
+		case discordgo.TextInput:
+			inputs = append(inputs, c)
+		}
+	}
+	return inputs
+}
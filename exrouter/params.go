@@ -0,0 +1,109 @@
+package exrouter
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Params holds the values of arguments parsed by a route's argument schema
+type Params map[string]interface{}
+
+// Has returns true if the argument was supplied
+func (p Params) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+// Get returns the value of an argument or nil if it was not supplied
+func (p Params) Get(name string) interface{} {
+	return p[name]
+}
+
+// String returns a string argument
+func (p Params) String(name string) string {
+	if v, ok := p[name].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Int returns an integer argument
+func (p Params) Int(name string) int {
+	if v, ok := p[name].(int); ok {
+		return v
+	}
+	return 0
+}
+
+// Float returns a floating point argument
+func (p Params) Float(name string) float64 {
+	if v, ok := p[name].(float64); ok {
+		return v
+	}
+	return 0
+}
+
+// Bool returns a boolean argument
+func (p Params) Bool(name string) bool {
+	if v, ok := p[name].(bool); ok {
+		return v
+	}
+	return false
+}
+
+// Duration returns a duration argument
+func (p Params) Duration(name string) time.Duration {
+	if v, ok := p[name].(time.Duration); ok {
+		return v
+	}
+	return 0
+}
+
+// Time returns a time argument
+func (p Params) Time(name string) time.Time {
+	if v, ok := p[name].(time.Time); ok {
+		return v
+	}
+	return time.Time{}
+}
+
+// User returns a user argument
+func (p Params) User(name string) *discordgo.User {
+	if v, ok := p[name].(*discordgo.User); ok {
+		return v
+	}
+	return nil
+}
+
+// Member returns a member argument
+func (p Params) Member(name string) *discordgo.Member {
+	if v, ok := p[name].(*discordgo.Member); ok {
+		return v
+	}
+	return nil
+}
+
+// Channel returns a channel argument
+func (p Params) Channel(name string) *discordgo.Channel {
+	if v, ok := p[name].(*discordgo.Channel); ok {
+		return v
+	}
+	return nil
+}
+
+// Role returns a role argument
+func (p Params) Role(name string) *discordgo.Role {
+	if v, ok := p[name].(*discordgo.Role); ok {
+		return v
+	}
+	return nil
+}
+
+// Emoji returns an emoji argument
+func (p Params) Emoji(name string) *discordgo.Emoji {
+	if v, ok := p[name].(*discordgo.Emoji); ok {
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package exrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Pipeline errors
+var (
+	ErrPipelineTooLong = errors.New("too many commands in pipeline")
+	ErrPipeNotAllowed  = errors.New("command does not accept piped input")
+	ErrPipelineTimeout = errors.New("pipeline took too long")
+)
+
+// PipeSeparator separates the commands of a pipeline
+const PipeSeparator = "|"
+
+// PipelineError is returned when a command of a pipeline fails
+type PipelineError struct {
+	// Stage is the position of the command in the pipeline, starting from 1
+	Stage   int
+	Command string
+	Err     error
+}
+
+func (e *PipelineError) Error() string {
+	return fmt.Sprintf("pipeline command %d (%s): %s", e.Stage, e.Command, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// pipelineStages splits the content of a command on unquoted pipe separators
+// It returns nil if the content is not a pipeline
+func pipelineStages(content string) []string {
+	tokens, err := dgrouter.LexTokens(content)
+	if err != nil {
+		return nil
+	}
+
+	var (
+		stages []string
+		last   int
+	)
+	for _, v := range tokens {
+		if content[v.Start:v.End] != PipeSeparator {
+			continue
+		}
+		stages = append(stages, strings.TrimSpace(content[last:v.Start]))
+		last = v.End
+	}
+	if stages == nil {
+		return nil
+	}
+	return append(stages, strings.TrimSpace(content[last:]))
+}
+
+// resolvePipeline resolves the commands of a pipeline before any of them are called
+// It returns nil if the message is a single command whose arguments contain a pipe separator,
+// ex. !poll yes | no. This is the case when a stage after the first is not a command,
+// or none of the commands after the first accept piped input.
+//    stages : text of each command
+func (d *Dispatcher) resolvePipeline(m *discordgo.Message, stages []string) ([]*Resolution, error) {
+	fromBot := d.IgnoreBots && m.Author != nil && m.Author.Bot
+
+	var (
+		resolved = make([]*Resolution, len(stages))
+		piped    bool
+	)
+	for i, v := range stages {
+		res, err := d.Router.Resolve(v, ResolveOptions{NoPrefix: true})
+		if err != nil || fromBot && !res.Route.BotsAllowed() {
+			return nil, nil
+		}
+		piped = piped || i > 0 && res.Route.PipeAllowed()
+		resolved[i] = res
+	}
+	if !piped {
+		return nil, nil
+	}
+
+	if len(stages) > d.MaxPipeline {
+		return nil, ErrPipelineTooLong
+	}
+	for i, res := range resolved[1:] {
+		if !res.Route.PipeAllowed() {
+			return nil, &PipelineError{i + 2, stages[i+1], ErrPipeNotAllowed}
+		}
+	}
+	return resolved, nil
+}
+
+// pipeline calls the commands of a pipeline in order
+// The replies of every command but the last are captured and passed to the next command
+// as its Input and as an additional argument
+//    prefix   : prefix used by the message
+//    stages   : text of each command
+//    resolved : the resolved commands, see resolvePipeline
+func (d *Dispatcher) pipeline(s *discordgo.Session, m *discordgo.Message, prefix string, stages []string, resolved []*Resolution) error {
+	deadline, cancel := context.Background(), func() {}
+	if d.PipelineTimeout > 0 {
+		deadline, cancel = context.WithTimeout(deadline, d.PipelineTimeout)
+	}
+	defer cancel()
+
+	var output string
+	for i, res := range resolved {
+		if deadline.Err() != nil {
+			return &PipelineError{i + 1, stages[i], ErrPipelineTimeout}
+		}
+
+		ctx := res.Context(s, m)
+		ctx.Prefix = prefix
+		ctx.deadline = deadline
+		if i > 0 {
+			ctx.Input = output
+			if output != "" {
+				ctx.Content += " " + output
+				ctx.Args = append(ctx.Args, output)
+				ctx.Tokens = append(ctx.Tokens, dgrouter.Token{
+					Value: output,
+					Start: len(ctx.Content) - len(output),
+					End:   len(ctx.Content),
+				})
+			}
+		}
+
+		if err := d.checkScope(ctx); err != nil {
+			return err
+		}
+		ctx.parse = func() error { return parseParams(ctx) }
+
+		last := i == len(resolved)-1
+		if last {
+			ctx.Replies = d.Replies
+		} else {
+			ctx.capture = &strings.Builder{}
+		}
+
+		// Commands run until they return, so a command that ignores ctx.Context() keeps its worker busy,
+		// but its replies are dropped once the pipeline is out of time
+		if err := d.execute(res.Route, ctx); err != nil {
+			return err
+		}
+		if deadline.Err() != nil {
+			return &PipelineError{i + 1, stages[i], ErrPipelineTimeout}
+		}
+
+		if !last {
+			output = ctx.captured()
+		}
+	}
+
+	return nil
+}
+
+// pipelineError replies to the sender with an error that stopped a pipeline
+// Errors of the commands themselves are left to the commands, ex. usage errors
+func pipelineError(ctx *Context, err error) error {
+	if err == ErrPipelineTooLong || errors.Is(err, ErrPipeNotAllowed) || errors.Is(err, ErrPipelineTimeout) {
+		ctx.Reply("error: ", err)
+	}
+	return err
+}
+
+// timedOut reports whether the pipeline the command is part of has run out of time
+func (c *Context) timedOut() bool {
+	return c.deadline != nil && c.deadline.Err() != nil
+}
+
+// captureReply records the content of a reply instead of sending it
+// It returns false if the command's replies are not captured.
+// The returned message only holds the content and channel of the reply
+func (c *Context) captureReply(content string) (*discordgo.Message, bool) {
+	c.imu.Lock()
+	defer c.imu.Unlock()
+	if c.capture == nil {
+		return nil, false
+	}
+	if c.capture.Len() > 0 {
+		c.capture.WriteString("\n")
+	}
+	c.capture.WriteString(content)
+	return &discordgo.Message{ChannelID: c.Msg.ChannelID, Content: content}, true
+}
+
+// stopCapture sends the command's later replies instead of capturing them
+func (c *Context) stopCapture() {
+	c.imu.Lock()
+	c.capture = nil
+	c.imu.Unlock()
+}
+
+// captured returns the replies captured from a command
+func (c *Context) captured() string {
+	c.imu.Lock()
+	defer c.imu.Unlock()
+	if c.capture == nil {
+		return ""
+	}
+	return c.capture.String()
+}
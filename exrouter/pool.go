@@ -0,0 +1,255 @@
+package exrouter
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Worker pool errors
+var (
+	ErrQueueFull    = errors.New("command queue is full")
+	ErrKeyQueueFull = errors.New("too many commands are queued for the same key")
+	ErrPoolClosed   = errors.New("worker pool is closed")
+)
+
+// OrderFunc returns the key of a message
+// Messages with the same key are dispatched one at a time, in the order they were received.
+// Messages with an empty key are not ordered
+type OrderFunc func(m *discordgo.Message) string
+
+// OrderByUser dispatches the commands of each user in order
+func OrderByUser(m *discordgo.Message) string {
+	if m.Author == nil {
+		return ""
+	}
+	return m.Author.ID
+}
+
+// OrderByChannel dispatches the commands sent in each channel in order
+func OrderByChannel(m *discordgo.Message) string {
+	return m.ChannelID
+}
+
+// OverflowPolicy controls what happens to messages received while the queue of a WorkerPool is full
+type OverflowPolicy int
+
+// Overflow policies
+const (
+	// OverflowBlock waits for room in the queue
+	// discordgo calls each event handler in a new goroutine unless Session.SyncEvents is set,
+	// so the waiting goroutines are not limited. With SyncEvents set,
+	// the session stops handling events until there is room in the queue
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowReject drops the message and reports ErrQueueFull to the dispatcher's OnError
+	OverflowReject
+)
+
+// WorkerPool dispatches messages using a fixed number of goroutines
+// discordgo calls every event handler in a new goroutine, so a burst of commands
+// would otherwise run all at once. Commands with the same order key run in order.
+//
+// example:
+// pool := exrouter.NewWorkerPool(exrouter.NewDispatcher(router, prefixes), 8, 100)
+// s.AddHandler(pool.Handle)
+// s.AddHandler(pool.HandleInteraction)
+type WorkerPool struct {
+	Dispatcher *Dispatcher
+
+	// Order returns the order key of a message, see OrderByUser
+	// Messages are not ordered if it is nil
+	Order OrderFunc
+
+	// Overflow controls what happens to messages received while the queue is full
+	Overflow OverflowPolicy
+
+	// MaxPerKey limits the number of commands with the same order key that can be queued or running,
+	// so one user can not fill the queue when ordering by user. Commands over the limit are
+	// rejected with ErrKeyQueueFull regardless of the overflow policy. It is not limited if it is 0
+	MaxPerKey int
+
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// size is the maximum number of jobs that have not started yet
+	size   int
+	queued int
+
+	// ready holds jobs that can start, and active holds the jobs
+	// waiting for the running job with the same key to finish
+	ready  chan *poolJob
+	active map[string][]*poolJob
+
+	// keys counts the queued and running jobs of each key
+	keys map[string]int
+
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type poolJob struct {
+	key string
+	fn  func()
+}
+
+// NewWorkerPool starts a worker pool that orders commands by user
+//    d         : dispatcher used to route messages
+//    workers   : number of commands that can run at the same time
+//    queueSize : number of commands that can wait for a worker
+func NewWorkerPool(d *Dispatcher, workers, queueSize int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	p := &WorkerPool{
+		Dispatcher: d,
+		Order:      OrderByUser,
+		size:       queueSize,
+		ready:      make(chan *poolJob, queueSize),
+		active:     map[string][]*poolJob{},
+		keys:       map[string]int{},
+	}
+	p.cond = sync.NewCond(&p.mu)
+
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Handle queues a MessageCreate event
+// It can be passed directly to session.AddHandler
+func (p *WorkerPool) Handle(s *discordgo.Session, m *discordgo.MessageCreate) {
+	p.Dispatch(s, m.Message)
+}
+
+// HandleInteraction queues an InteractionCreate event
+// It can be passed directly to session.AddHandler
+func (p *WorkerPool) HandleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	p.submit(s, interactionMessage(i.Interaction), func() {
+		p.Dispatcher.DispatchInteraction(s, i.Interaction)
+	})
+}
+
+// Dispatch queues a message to be dispatched by the pool's dispatcher
+// It returns ErrQueueFull or ErrKeyQueueFull if the message was rejected, or ErrPoolClosed if the pool was closed
+func (p *WorkerPool) Dispatch(s *discordgo.Session, m *discordgo.Message) error {
+	return p.submit(s, m, func() {
+		p.Dispatcher.Dispatch(s, m)
+	})
+}
+
+// submit queues fn using the order key of m, reporting rejected messages to OnError
+func (p *WorkerPool) submit(s *discordgo.Session, m *discordgo.Message, fn func()) error {
+	key := ""
+	if p.Order != nil {
+		key = p.Order(m)
+	}
+
+	err := p.Go(key, fn)
+	if (err == ErrQueueFull || err == ErrKeyQueueFull) && p.Dispatcher.OnError != nil {
+		p.Dispatcher.OnError(s, m, err)
+	}
+	return err
+}
+
+// Go queues a function to run on the pool
+// Functions with the same non empty key run in the order they were queued
+//    key : order key, see OrderFunc
+//    fn  : function to run
+func (p *WorkerPool) Go(key string, fn func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The key is checked again after waiting, since other jobs with the same key may have been queued
+	for {
+		if p.closed {
+			return ErrPoolClosed
+		}
+		if key != "" && p.MaxPerKey > 0 && p.keys[key] >= p.MaxPerKey {
+			return ErrKeyQueueFull
+		}
+		if p.queued < p.size {
+			break
+		}
+		if p.Overflow == OverflowReject {
+			return ErrQueueFull
+		}
+		p.cond.Wait()
+	}
+
+	job := &poolJob{key, fn}
+	p.queued++
+	p.wg.Add(1)
+
+	if key != "" {
+		p.keys[key]++
+		if waiting, ok := p.active[key]; ok {
+			p.active[key] = append(waiting, job)
+			return nil
+		}
+		p.active[key] = nil
+	}
+
+	// ready can hold every queued job, so this never blocks
+	p.ready <- job
+	return nil
+}
+
+// Close stops accepting commands and waits for the queued ones to finish
+func (p *WorkerPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	close(p.ready)
+}
+
+// work runs jobs until the pool is closed
+func (p *WorkerPool) work() {
+	for job := range p.ready {
+		// Every waiting submitter is woken, since the first may give up because of its key
+		p.mu.Lock()
+		p.queued--
+		p.cond.Broadcast()
+		p.mu.Unlock()
+
+		p.run(job)
+	}
+}
+
+// run calls a job and starts the next job with the same key
+func (p *WorkerPool) run(job *poolJob) {
+	defer p.wg.Done()
+	defer func() {
+		if job.key == "" {
+			return
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.keys[job.key]--; p.keys[job.key] <= 0 {
+			delete(p.keys, job.key)
+		}
+		waiting := p.active[job.key]
+		if len(waiting) == 0 {
+			delete(p.active, job.key)
+			return
+		}
+		p.active[job.key] = waiting[1:]
+		p.ready <- waiting[0]
+	}()
+
+	job.fn()
+}
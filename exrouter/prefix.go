@@ -0,0 +1,97 @@
+package exrouter
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PrefixResolver returns the prefixes that a message may use to call a command
+type PrefixResolver interface {
+	Prefixes(m *discordgo.Message) []string
+}
+
+// PrefixResolverFunc is a function that implements PrefixResolver
+type PrefixResolverFunc func(m *discordgo.Message) []string
+
+// Prefixes calls the function
+func (fn PrefixResolverFunc) Prefixes(m *discordgo.Message) []string {
+	return fn(m)
+}
+
+// StaticPrefix is a single prefix used for every message
+type StaticPrefix string
+
+// Prefixes returns the prefix
+func (p StaticPrefix) Prefixes(m *discordgo.Message) []string {
+	return []string{string(p)}
+}
+
+// MultiPrefix is a list of prefixes used for every message
+type MultiPrefix []string
+
+// Prefixes returns the prefixes
+func (p MultiPrefix) Prefixes(m *discordgo.Message) []string {
+	return p
+}
+
+// PrefixStore stores the prefixes chosen by each guild
+// Guilds without prefixes, and direct messages, use the default prefixes
+type PrefixStore struct {
+	mu     sync.RWMutex
+	guilds map[string][]string
+
+	// Default prefixes used when a guild has none set
+	Default []string
+}
+
+// NewPrefixStore returns a new prefix store
+//    defaults : prefixes used when a guild has none set
+func NewPrefixStore(defaults ...string) *PrefixStore {
+	return &PrefixStore{
+		guilds:  map[string][]string{},
+		Default: defaults,
+	}
+}
+
+// Set sets the prefixes of a guild
+// Calling Set with no prefixes resets the guild to the default prefixes
+func (p *PrefixStore) Set(guildID string, prefixes ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(prefixes) == 0 {
+		delete(p.guilds, guildID)
+		return
+	}
+	p.guilds[guildID] = prefixes
+}
+
+// Get returns the prefixes of a guild
+func (p *PrefixStore) Get(guildID string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if v, ok := p.guilds[guildID]; ok {
+		return v
+	}
+	return p.Default
+}
+
+// Prefixes returns the prefixes of the guild the message was sent in
+func (p *PrefixStore) Prefixes(m *discordgo.Message) []string {
+	return p.Get(m.GuildID)
+}
+
+// matchPrefix returns the longest prefix that the content starts with
+func matchPrefix(content string, prefixes []string) (string, bool) {
+	var (
+		match string
+		found bool
+	)
+	for _, v := range prefixes {
+		if v != "" && len(v) > len(match) && strings.HasPrefix(content, v) {
+			match, found = v, true
+		}
+	}
+	return match, found
+}
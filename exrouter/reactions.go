@@ -0,0 +1,198 @@
+package exrouter
+
+import (
+	"strings"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Route kinds used for reactions
+const (
+	KindReactionAdd    = "reaction_add"
+	KindReactionRemove = "reaction_remove"
+)
+
+// AnyEmoji matches reactions with any emoji
+const AnyEmoji = "*"
+
+// OnReaction registers a handler for reactions added to messages
+// Every route matching the emoji is called, so several features can use the same emoji.
+// Routes can be limited to some messages with filters, ex. OnMessages and OwnMessages
+//
+// example:
+// router.OnReaction("⭐", bookmark).Filter(exrouter.OwnMessages)
+//    emoji   : unicode emoji, custom emoji name or ID, or AnyEmoji
+//    handler : handler function
+func (r *Route) OnReaction(emoji string, handler HandlerFunc) *Route {
+	return &Route{r.Route.AddEvent(KindReactionAdd, emoji, emojiMatcher(emoji), WrapHandler(handler))}
+}
+
+// OnReactionRemove registers a handler for reactions removed from messages
+// See OnReaction
+//    emoji   : unicode emoji, custom emoji name or ID, or AnyEmoji
+//    handler : handler function
+func (r *Route) OnReactionRemove(emoji string, handler HandlerFunc) *Route {
+	return &Route{r.Route.AddEvent(KindReactionRemove, emoji, emojiMatcher(emoji), WrapHandler(handler))}
+}
+
+// emojiMatcher returns a matcher for the API name of an emoji, ex. ⭐ or party:222222222222222222
+// Custom emojis can be matched by their name, ID or API name
+func emojiMatcher(emoji string) func(string) bool {
+	emoji = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(emoji, "<a:"), "<:"), ">")
+	return func(name string) bool {
+		return emoji == AnyEmoji || name == emoji ||
+			strings.HasPrefix(name, emoji+":") || strings.HasSuffix(name, ":"+emoji)
+	}
+}
+
+// Filter wraps a filter function that takes an exrouter.Context
+func Filter(fn func(ctx *Context) bool) dgrouter.FilterFunc {
+	return func(ctx interface{}) bool {
+		return fn(ctx.(*Context))
+	}
+}
+
+// OnMessages returns a filter that only allows reactions on the given messages
+// It only uses the ID of the message, so the message does not need to be retrieved
+func OnMessages(messageIDs ...string) dgrouter.FilterFunc {
+	return Filter(func(ctx *Context) bool {
+		for _, v := range messageIDs {
+			if ctx.TargetMessage != nil && ctx.TargetMessage.ID == v {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// OwnMessages is a filter that only allows reactions on messages sent by the bot
+// The message is retrieved to find its author, see Context.Target
+var OwnMessages = Filter(func(ctx *Context) bool {
+	m := ctx.Target()
+	return m != nil && m.Author != nil && ctx.Ses != nil && ctx.Ses.State != nil &&
+		ctx.Ses.State.User != nil && m.Author.ID == ctx.Ses.State.User.ID
+})
+
+// HandleReactionAdd dispatches a MessageReactionAdd event
+// It can be passed directly to session.AddHandler
+func (d *Dispatcher) HandleReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	d.DispatchReaction(s, r.MessageReaction, r.Member, true)
+}
+
+// HandleReactionRemove dispatches a MessageReactionRemove event
+// It can be passed directly to session.AddHandler
+func (d *Dispatcher) HandleReactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	d.DispatchReaction(s, r.MessageReaction, nil, false)
+}
+
+// DispatchReaction calls every route matching a reaction
+// Context.Msg describes the reacting user and member, and Context.TargetMessage
+// is the message that was reacted to. Context.Args holds the API name of the emoji.
+// The message is only retrieved for routes whose filters allow the reaction
+// Reactions by the bot itself are ignored if IgnoreSelf is set
+//    r      : reaction that was added or removed
+//    member : member that reacted, if known
+//    added  : true if the reaction was added, false if it was removed
+func (d *Dispatcher) DispatchReaction(s *discordgo.Session, r *discordgo.MessageReaction, member *discordgo.Member, added bool) error {
+	err := d.dispatchReaction(s, r, member, added)
+	if err != nil && err != dgrouter.ErrCouldNotFindRoute && d.OnError != nil {
+		d.OnError(s, reactionMessage(r, member), err)
+	}
+	return err
+}
+
+func (d *Dispatcher) dispatchReaction(s *discordgo.Session, r *discordgo.MessageReaction, member *discordgo.Member, added bool) error {
+	if d.IgnoreSelf && r.UserID == d.botID(s) {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+
+	kind := KindReactionAdd
+	if !added {
+		kind = KindReactionRemove
+	}
+
+	emoji := r.Emoji.APIName()
+	routes := d.Router.FindAllKind(kind, emoji)
+	if len(routes) == 0 {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+
+	// Filters see a message that only holds its IDs, and the message is only retrieved
+	// once a route allows the reaction or a filter asks for it with Context.Target
+	var target *discordgo.Message
+	fetch := func() *discordgo.Message {
+		if target == nil {
+			target = reactionTarget(s, r)
+		}
+		return target
+	}
+
+	var (
+		called   bool
+		firstErr error
+	)
+	for _, rt := range routes {
+		ctx := NewContext(s, reactionMessage(r, member), Args{emoji}, rt)
+		ctx.TargetMessage = target
+		if target == nil {
+			ctx.TargetMessage = &discordgo.Message{ID: r.MessageID, ChannelID: r.ChannelID, GuildID: r.GuildID}
+		}
+		ctx.fetchTarget = fetch
+		if !rt.Allows(ctx) {
+			continue
+		}
+		ctx.Target()
+
+		called = true
+		if err := d.execute(rt, ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if !called {
+		return dgrouter.ErrCouldNotFindRoute
+	}
+	return firstErr
+}
+
+// Target returns the message that was reacted to, retrieving it the first time it is needed
+// Filters of reaction routes only see the IDs of the message in TargetMessage,
+// so filters that need its content or author should use Target instead
+func (c *Context) Target() *discordgo.Message {
+	if c.fetchTarget != nil {
+		c.TargetMessage = c.fetchTarget()
+		c.fetchTarget = nil
+	}
+	return c.TargetMessage
+}
+
+// reactionTarget retrieves the message that was reacted to from the state or restapi
+// If it can not be retrieved, the returned message only holds its IDs
+func reactionTarget(s *discordgo.Session, r *discordgo.MessageReaction) *discordgo.Message {
+	if s != nil {
+		if s.State != nil {
+			if m, err := s.State.Message(r.ChannelID, r.MessageID); err == nil {
+				return m
+			}
+		}
+		if m, err := s.ChannelMessage(r.ChannelID, r.MessageID); err == nil {
+			return m
+		}
+	}
+	return &discordgo.Message{ID: r.MessageID, ChannelID: r.ChannelID, GuildID: r.GuildID}
+}
+
+// reactionMessage returns a message describing who reacted and where
+func reactionMessage(r *discordgo.MessageReaction, member *discordgo.Member) *discordgo.Message {
+	m := &discordgo.Message{
+		ChannelID: r.ChannelID,
+		GuildID:   r.GuildID,
+		Author:    &discordgo.User{ID: r.UserID},
+		Member:    member,
+	}
+	if member != nil && member.User != nil {
+		m.Author = member.User
+	}
+	return m
+}
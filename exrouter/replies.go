@@ -0,0 +1,113 @@
+package exrouter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultEditWindow is how long after a command is sent that editing it calls the command again
+const DefaultEditWindow = time.Minute * 5
+
+// ReplyTracker remembers the replies sent to each command
+// When an edited command is dispatched again, its replies edit the previous responses
+// instead of sending new messages, and the previous responses it no longer needs are deleted
+type ReplyTracker struct {
+	mu      sync.Mutex
+	replies map[string]*trackedReplies
+
+	// Window is how long replies are remembered for
+	Window time.Duration
+}
+
+type trackedReplies struct {
+	created  time.Time
+	messages []*discordgo.Message
+
+	// used is the number of replies sent or edited by the current run of the command
+	used int
+}
+
+// NewReplyTracker returns a new reply tracker
+//    window : how long replies are remembered for, DefaultEditWindow is used if it is zero
+func NewReplyTracker(window time.Duration) *ReplyTracker {
+	if window <= 0 {
+		window = DefaultEditWindow
+	}
+	return &ReplyTracker{
+		replies: map[string]*trackedReplies{},
+		Window:  window,
+	}
+}
+
+// reply edits the nth previous reply to the context's message if one exists,
+// otherwise it sends a new reply and remembers it
+//    send : sends a new message
+//    edit : edits an existing message
+func (t *ReplyTracker) reply(ctx *Context, send func() (*discordgo.Message, error), edit func(prev *discordgo.Message) (*discordgo.Message, error)) (*discordgo.Message, error) {
+	n := ctx.replies
+	ctx.replies++
+
+	if prev := t.use(ctx.Msg.ID, n); prev != nil {
+		return edit(prev)
+	}
+
+	m, err := send()
+	if err == nil {
+		t.add(ctx.Msg.ID, m)
+	}
+	return m, err
+}
+
+// use returns the nth reply to a message and marks it as used by the current run of the command
+func (t *ReplyTracker) use(messageID string, n int) *discordgo.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r, ok := t.replies[messageID]
+	if !ok || n >= len(r.messages) {
+		return nil
+	}
+	if n >= r.used {
+		r.used = n + 1
+	}
+	return r.messages[n]
+}
+
+// finish deletes the replies to a message that were not used by the last run of its command,
+// ex. when an edited command replies fewer times than the original
+func (t *ReplyTracker) finish(s *discordgo.Session, messageID string) {
+	t.mu.Lock()
+	var unused []*discordgo.Message
+	if r, ok := t.replies[messageID]; ok {
+		unused = r.messages[r.used:]
+		r.messages = r.messages[:r.used:r.used]
+		r.used = 0
+	}
+	t.mu.Unlock()
+
+	for _, v := range unused {
+		s.ChannelMessageDelete(v.ChannelID, v.ID)
+	}
+}
+
+// add remembers a reply to a message and forgets replies older than the window
+func (t *ReplyTracker) add(messageID string, reply *discordgo.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for k, v := range t.replies {
+		if now.Sub(v.created) > t.Window {
+			delete(t.replies, k)
+		}
+	}
+
+	r, ok := t.replies[messageID]
+	if !ok {
+		r = &trackedReplies{created: now}
+		t.replies[messageID] = r
+	}
+	r.messages = append(r.messages, reply)
+	r.used = len(r.messages)
+}
@@ -0,0 +1,186 @@
+package exrouter
+
+import (
+	"strings"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// ResolveOptions controls how a message is resolved to a route
+type ResolveOptions struct {
+	// Prefixes the message may use
+	Prefixes []string
+
+	// BotID is the user ID of the bot, used to recognize mentions
+	BotID string
+
+	// MentionPrefix allows a mention of the bot to be used as a prefix
+	// A message containing only a mention resolves to the router's Default route
+	MentionPrefix bool
+
+	// NoPrefix allows the message to call a command without a prefix, ex. in direct messages
+	NoPrefix bool
+
+	// Normalize is called on the content before it is resolved, ex. NormalizeSpace
+	Normalize func(content string) string
+}
+
+// Resolution is the route a message would call
+type Resolution struct {
+	Route *dgrouter.Route
+
+	// Path is the text of the route names that were consumed, as they were written
+	// It is empty if the message was only a mention of the bot
+	Path []string
+
+	// Prefix is the prefix the message used
+	Prefix string
+
+	// Mention is true if the message used a mention of the bot as its prefix
+	Mention bool
+
+	// Content is the text of the command after the prefix
+	Content string
+
+	// Args are the arguments of the command, in the same form as Context.Args
+	// The first argument is the joined path
+	Args Args
+
+	// Tokens are the tokens of the arguments, in the same form as Context.Tokens
+	Tokens []dgrouter.Token
+}
+
+// Resolve finds the route that a message would call without executing it
+// It returns dgrouter.ErrCouldNotFindRoute if the message does not call a route.
+// If a quote or code block is left unclosed, the route is resolved from the text before it
+// and returned along with the *dgrouter.LexError, so the error can be reported to the user
+//
+// example:
+// res, err := router.Resolve("!ban @user spam", exrouter.ResolveOptions{Prefixes: []string{"!"}})
+// fmt.Println(res.Route.Name, res.Args.After(1))
+//    content : content of the message
+//    opts    : options used to resolve the message
+func (r *Route) Resolve(content string, opts ResolveOptions) (*Resolution, error) {
+	if opts.Normalize != nil {
+		content = opts.Normalize(content)
+	}
+
+	// If the message content is only a bot mention, the mention route is resolved
+	if opts.MentionPrefix && r.Default != nil && (content == mention(opts.BotID) || content == nickMention(opts.BotID)) {
+		return &Resolution{
+			Route:   r.Default,
+			Prefix:  content,
+			Mention: true,
+			Args:    Args{""},
+		}, nil
+	}
+
+	var candidates []string
+	if opts.MentionPrefix {
+		// Bot mentions followed by a space can always be used as a prefix
+		candidates = append(candidates, mention(opts.BotID)+" ", nickMention(opts.BotID)+" ")
+	}
+	candidates = append(candidates, opts.Prefixes...)
+
+	pf, ok := matchPrefix(content, candidates)
+	if !ok && !opts.NoPrefix {
+		return nil, dgrouter.ErrCouldNotFindRoute
+	}
+
+	command := strings.TrimPrefix(content, pf)
+	tokens, err := dgrouter.LexTokens(command)
+	if lerr, ok := err.(*dgrouter.LexError); ok {
+		// The text before the unclosed quote can still name the route
+		tokens, _ = dgrouter.LexTokens(command[:lerr.Offset])
+	} else if err != nil {
+		return nil, err
+	}
+	args := tokenArgs(tokens)
+
+	rt, depth := r.FindFull(args...)
+	if depth == 0 {
+		return nil, dgrouter.ErrCouldNotFindRoute
+	}
+
+	res := &Resolution{
+		Route:   rt,
+		Path:    args[:depth],
+		Prefix:  pf,
+		Mention: opts.MentionPrefix && (pf == mention(opts.BotID)+" " || pf == nickMention(opts.BotID)+" "),
+		Content: command,
+		Args:    append(Args{strings.Join(args[:depth], string(separator))}, args[depth:]...),
+	}
+	res.Tokens = append([]dgrouter.Token{{
+		Value: res.Args[0],
+		Start: tokens[0].Start,
+		End:   tokens[depth-1].End,
+	}}, tokens[depth:]...)
+	return res, err
+}
+
+// Resolve finds the route that a message would call using the dispatcher's options,
+// without executing it. Messages the dispatcher ignores are not resolved
+// Unclosed quotes are reported the same way as by Route.Resolve
+func (d *Dispatcher) Resolve(s *discordgo.Session, m *discordgo.Message) (*Resolution, error) {
+	botID := d.botID(s)
+	if d.ignored(m, botID) {
+		return nil, dgrouter.ErrCouldNotFindRoute
+	}
+
+	dm := m.GuildID == ""
+	if dm && d.DirectMessages == DMIgnore {
+		return nil, dgrouter.ErrCouldNotFindRoute
+	}
+
+	opts := ResolveOptions{
+		BotID:         botID,
+		MentionPrefix: d.MentionPrefix,
+		NoPrefix:      dm && d.DirectMessages == DMNoPrefix,
+		Normalize:     d.Normalize,
+	}
+	if d.Prefixes != nil {
+		opts.Prefixes = d.Prefixes.Prefixes(m)
+	}
+
+	res, err := d.Router.Resolve(m.Content, opts)
+	if res == nil {
+		return nil, err
+	}
+
+	// Bots are only ignored once it is known whether their route allows them
+	if d.IgnoreBots && m.Author != nil && m.Author.Bot && !res.Route.BotsAllowed() {
+		return nil, dgrouter.ErrCouldNotFindRoute
+	}
+	return res, err
+}
+
+// Context returns a context for the resolved route, as the dispatcher creates it before calling the route
+// The flags and arguments are not parsed, see Params
+//    s : session passed to argument parsers and handlers
+//    m : message that was resolved
+func (res *Resolution) Context(s *discordgo.Session, m *discordgo.Message) *Context {
+	ctx := NewContext(s, m, res.Args, res.Route)
+	ctx.Prefix = res.Prefix
+	ctx.Content = res.Content
+	ctx.Tokens = res.Tokens
+	return ctx
+}
+
+// Params parses the flags and arguments of the resolved route without executing it
+// The context's Args, Flags and Params are set as they are for the route's handler,
+// but parsing errors are returned without replying to the sender
+//
+// example:
+// ctx := res.Context(s, m)
+// if err := res.Params(ctx); err == nil {
+//     fmt.Println(ctx.Params.String("reason"))
+// }
+//    ctx : context of the resolution, see Context
+func (res *Resolution) Params(ctx *Context) error {
+	// A mention of the bot on its own has no arguments
+	if len(res.Path) == 0 {
+		return nil
+	}
+	return parseTokens(ctx)
+}
@@ -1,8 +1,6 @@
 package exrouter
 
 import (
-	"strings"
-
 	"github.com/Necroforger/dgrouter"
 	"github.com/bwmarrin/discordgo"
 )
@@ -52,7 +50,7 @@ func (r *Route) Use(fn ...MiddlewareFunc) *Route {
 func WrapMiddleware(mware MiddlewareFunc) dgrouter.MiddlewareFunc {
 	return func(next dgrouter.HandlerFunc) dgrouter.HandlerFunc {
 		return func(i interface{}) {
-			WrapHandler(mware(UnwrapHandler(next)))(i)
+			mware(UnwrapHandler(next))(i.(*Context))
 		}
 	}
 }
@@ -79,54 +77,102 @@ func nickMention(id string) string {
 //    botID        : user ID of the bot to allow you to substitute the bot ID for a prefix
 //    m            : discord message to pass to context
 func (r *Route) FindAndExecute(s *discordgo.Session, prefix string, botID string, m *discordgo.Message) error {
-	var pf string
+	return r.FindAndExecuteResolver(s, StaticPrefix(prefix), botID, m)
+}
 
-	// If the message content is only a bot mention and the mention route is not nil, send the mention route
-	if r.Default != nil && m.Content == mention(botID) || r.Default != nil && m.Content == nickMention(botID) {
-		r.Default.Handler(NewContext(s, m, []string{""}, r.Default))
-		return nil
+// FindAndExecuteResolver is the same as FindAndExecute, but looks up the prefixes of the message
+// using a PrefixResolver. If several prefixes match, the longest one is used.
+// The prefix that was used is recorded in Context.Prefix
+// Messages from bots, webhooks, the system and the bot itself are ignored,
+// unless the route allows bots with dgrouter.Route.Bots
+//    s            : discordgo session to pass to context
+//    prefixes     : resolver that returns the prefixes you want the bot to respond to
+//    botID        : user ID of the bot to allow you to substitute the bot ID for a prefix
+//    m            : discord message to pass to context
+func (r *Route) FindAndExecuteResolver(s *discordgo.Session, prefixes PrefixResolver, botID string, m *discordgo.Message) error {
+	d := &Dispatcher{
+		Router:         r,
+		Prefixes:       prefixes,
+		BotID:          botID,
+		IgnoreBots:     true,
+		IgnoreSelf:     true,
+		IgnoreWebhooks: true,
+		IgnoreSystem:   true,
+		MentionPrefix:  true,
 	}
+	return d.Dispatch(s, m)
+}
 
-	// Append a space to the mentions
-	bmention := mention(botID) + " "
-	nmention := nickMention(botID) + " "
-
-	p := func(t string) bool {
-		return strings.HasPrefix(m.Content, t)
+// parseParams separates the route's flags from the context's arguments
+// and parses the remaining arguments using the route's argument schema
+// If parsing fails, the sender is sent the error along with the route's usage
+func parseParams(ctx *Context) error {
+	if err := parseTokens(ctx); err != nil {
+		return usageError(ctx, err)
 	}
+	return nil
+}
 
-	switch {
-	case prefix != "" && p(prefix):
-		pf = prefix
-	case p(bmention):
-		pf = bmention
-	case p(nmention):
-		pf = nmention
-	default:
-		return dgrouter.ErrCouldNotFindRoute
+// parseTokens parses the flags and arguments of the context's tokens without replying
+func parseTokens(ctx *Context) error {
+	if len(ctx.Route.Flags) > 0 {
+		tokens, flags, err := dgrouter.ParseFlagTokens(ctx.Tokens[1:], ctx.Route.Flags)
+		if err != nil {
+			return err
+		}
+		ctx.Tokens = append(ctx.Tokens[:1:1], tokens...)
+		ctx.Args = append(Args{ctx.Args[0]}, tokenArgs(tokens)...)
+		ctx.Flags = flags
 	}
 
-	command := strings.TrimPrefix(m.Content, pf)
-	args := ParseArgs(command)
-
-	if rt, depth := r.FindFull(args...); depth > 0 {
-		args = append([]string{strings.Join(args[:depth], string(separator))}, args[depth:]...)
-		rt.Handler(NewContext(s, m, args, rt))
-	} else {
-		return dgrouter.ErrCouldNotFindRoute
+	if len(ctx.Route.Arguments) > 0 {
+		params, err := ctx.Route.ParseArgumentTokens(ctx, ctx.Content, ctx.Tokens[1:])
+		if err != nil {
+			return err
+		}
+		ctx.Params = params
 	}
 
 	return nil
 }
 
+// usageError replies to the sender with an error and the route's usage
+// Routes that are not commands do not have a usage, so only the error is sent
+// The error is sent even if the output of the command is piped into another command
+func usageError(ctx *Context, err error) error {
+	ctx.stopCapture()
+	if ctx.Route.Kind != "" {
+		ctx.Reply("error: ", err)
+		return err
+	}
+	ctx.Reply("error: ", err, "\nusage: `", ctx.Prefix, ctx.Route.Usage(), "`")
+	return err
+}
+
 // WrapHandler wraps a dgrouter.HandlerFunc
+// The arguments of the context are parsed before fn is called, after the route's middleware has run,
+// so middleware can reject a command before its arguments are looked up
 func WrapHandler(fn HandlerFunc) dgrouter.HandlerFunc {
 	if fn == nil {
 		return nil
 	}
 	return func(i interface{}) {
-		fn(i.(*Context))
+		ctx := i.(*Context)
+		if ctx.parseArgs() != nil {
+			return
+		}
+		fn(ctx)
+	}
+}
+
+// parseArgs calls the context's pending argument parser once and returns its error
+func (c *Context) parseArgs() error {
+	if c.parse != nil {
+		parse := c.parse
+		c.parse = nil
+		c.parseErr = parse()
 	}
+	return c.parseErr
 }
 
 // UnwrapHandler unwraps a handler
@@ -1,11 +1,18 @@
 package exrouter_test
 
 import (
+	"errors"
 	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Necroforger/dgrouter"
 	"github.com/Necroforger/dgrouter/exrouter"
+	"github.com/Necroforger/dgrouter/exrouter/arg"
+	"github.com/Necroforger/dgrouter/timeparse"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -69,3 +76,942 @@ func TestRouter(t *testing.T) {
 		}
 	}
 }
+
+func TestRawAfter(t *testing.T) {
+	r := exrouter.New()
+
+	var called bool
+	r.On("tag", nil).On("create", func(ctx *exrouter.Context) {
+		called = true
+		if raw := ctx.RawAfter(2); raw != "some   \"quoted\"\ntext" {
+			t.Errorf("unexpected raw text: %q", raw)
+		}
+		if ctx.Args.Get(0) != "tag create" || ctx.Args.Get(1) != "name" {
+			t.Errorf("unexpected arguments: %q", ctx.Args)
+		}
+	})
+
+	msg := &discordgo.Message{Content: "!tag create name some   \"quoted\"\ntext"}
+	if err := r.FindAndExecute(nil, "!", "botid", msg); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("handler was not called")
+	}
+}
+
+func TestTimeArgs(t *testing.T) {
+	args := exrouter.Args{"remind", "1", "day", "and", "2", "hours", "stretch"}
+	if d, n, err := args.Duration(1); err != nil || d != time.Hour*26 || n != 5 {
+		t.Errorf("Duration(1) = %v, %d, %v", d, n, err)
+	}
+	if _, _, err := args.Duration(6); err != timeparse.ErrInvalidDuration {
+		t.Errorf("expected ErrInvalidDuration, got %v", err)
+	}
+	if _, _, err := (exrouter.Args{"999999999", "weeks", "x"}).Duration(0); err != timeparse.ErrDurationOutOfRange {
+		t.Errorf("expected ErrDurationOutOfRange, got %v", err)
+	}
+
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	args = exrouter.Args{"remind", "2024-02-01", "10:00", "stretch"}
+	if tm, n, err := args.Time(1, loc); err != nil || n != 2 || !tm.Equal(time.Date(2024, 2, 1, 10, 0, 0, 0, loc)) {
+		t.Errorf("Time(1) = %v, %d, %v", tm, n, err)
+	}
+	if _, _, err := args.Time(3, loc); err != timeparse.ErrInvalidTime {
+		t.Errorf("expected ErrInvalidTime, got %v", err)
+	}
+
+	// Flags and bound structs accept the same durations
+	r := exrouter.New()
+	var (
+		called bool
+		params struct {
+			For time.Duration `flag:"for"`
+		}
+	)
+	r.On("mute", func(ctx *exrouter.Context) {
+		called = true
+		if d := ctx.Flags.Duration("for"); d != time.Hour*48 {
+			t.Errorf("unexpected flag duration: %v", d)
+		}
+		if err := ctx.Bind(&params); err != nil || params.For != time.Hour*48 {
+			t.Errorf("unexpected bound duration: %v %v", params.For, err)
+		}
+	}).Flag(dgrouter.NewFlag("for", ""))
+
+	d := exrouter.NewDispatcher(r, exrouter.StaticPrefix("!"))
+	if err := d.Dispatch(nil, &discordgo.Message{Content: "!mute --for 2d", Author: &discordgo.User{ID: "user"}}); err != nil || !called {
+		t.Errorf("expected the handler to be called, got %v", err)
+	}
+}
+
+func TestTimezones(t *testing.T) {
+	tokyo := time.FixedZone("UTC+9", 9*60*60)
+	berlin := time.FixedZone("UTC+1", 60*60)
+
+	tz := exrouter.NewTimezones(nil)
+	tz.SetGuild("g1", berlin)
+	tz.SetUser("u1", tokyo)
+
+	tests := []struct {
+		user, guild string
+		want        *time.Location
+	}{
+		{"u1", "g1", tokyo},
+		{"u2", "g1", berlin},
+		{"u2", "g2", time.UTC},
+	}
+	for _, v := range tests {
+		ctx := exrouter.NewContext(nil, &discordgo.Message{GuildID: v.guild, Author: &discordgo.User{ID: v.user}}, nil, nil)
+		if loc := tz.Lookup(ctx); loc != v.want {
+			t.Errorf("Lookup(%s, %s) = %v, want %v", v.user, v.guild, loc, v.want)
+		}
+	}
+
+	tz.Default = berlin
+	ctx := exrouter.NewContext(nil, &discordgo.Message{}, nil, nil)
+	if loc := tz.Lookup(ctx); loc != berlin {
+		t.Errorf("expected the default timezone, got %v", loc)
+	}
+
+	ctx.Location = tokyo
+	if loc := ctx.Timezone(); loc != tokyo {
+		t.Errorf("expected the context's location, got %v", loc)
+	}
+}
+
+func TestPrefixResolver(t *testing.T) {
+	r := exrouter.New()
+
+	var prefix string
+	r.On("ping", func(ctx *exrouter.Context) {
+		prefix = ctx.Prefix
+	})
+
+	store := exrouter.NewPrefixStore("!", "!!")
+	store.Set("guild", "?")
+
+	tests := []struct {
+		content string
+		guildID string
+		prefix  string
+	}{
+		{"!ping", "", "!"},
+		{"!!ping", "", "!!"},
+		{"?ping", "guild", "?"},
+		{"<@botid> ping", "guild", "<@botid> "},
+	}
+
+	for _, v := range tests {
+		prefix = ""
+		msg := &discordgo.Message{Content: v.content, GuildID: v.guildID}
+		if err := r.FindAndExecuteResolver(nil, store, "botid", msg); err != nil {
+			t.Errorf("%q: %v", v.content, err)
+		}
+		if prefix != v.prefix {
+			t.Errorf("%q: expected prefix %q, got %q", v.content, v.prefix, prefix)
+		}
+	}
+
+	// The guild's prefix replaces the default prefixes
+	msg := &discordgo.Message{Content: "!ping", GuildID: "guild"}
+	if err := r.FindAndExecuteResolver(nil, store, "botid", msg); err != dgrouter.ErrCouldNotFindRoute {
+		t.Errorf("expected ErrCouldNotFindRoute, got %v", err)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	r := exrouter.New()
+
+	var calls int
+	r.On("ping", func(ctx *exrouter.Context) { calls++ })
+	r.On("panic", func(ctx *exrouter.Context) { panic("oops") })
+
+	var errs []error
+	d := exrouter.NewDispatcher(r, exrouter.StaticPrefix("!"))
+	d.BotID = "botid"
+	d.DirectMessages = exrouter.DMNoPrefix
+	d.Normalize = exrouter.NormalizeSpace
+	d.OnError = func(s *discordgo.Session, m *discordgo.Message, err error) {
+		errs = append(errs, err)
+	}
+
+	send := func(content, authorID string, bot bool, guildID string) {
+		d.Handle(nil, &discordgo.MessageCreate{Message: &discordgo.Message{
+			Content: content,
+			GuildID: guildID,
+			Author:  &discordgo.User{ID: authorID, Bot: bot},
+		}})
+	}
+
+	send("!ping", "user", false, "guild")
+	send(" !ping ", "user", false, "guild")
+	send("ping", "user", false, "")
+	send("ping", "user", false, "guild")
+	send("!ping", "otherbot", true, "guild")
+	send("!ping", "botid", false, "guild")
+
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	send("!panic", "user", false, "guild")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(errs))
+	}
+	if _, ok := errs[0].(*exrouter.PanicError); !ok {
+		t.Errorf("expected a PanicError, got %v", errs[0])
+	}
+}
+
+func TestBotFilter(t *testing.T) {
+	r := exrouter.New()
+
+	called := map[string]int{}
+	r.On("human", func(ctx *exrouter.Context) { called["human"]++ })
+	r.On("relay", func(ctx *exrouter.Context) { called["relay"]++ }).Bots()
+
+	messages := []*discordgo.Message{
+		{Content: "!human", Author: &discordgo.User{ID: "bot", Bot: true}},
+		{Content: "!relay", Author: &discordgo.User{ID: "bot", Bot: true}},
+		{Content: "!relay", Author: &discordgo.User{ID: "botid", Bot: true}},
+		{Content: "!relay", Author: &discordgo.User{ID: "hook", Bot: true}, WebhookID: "hook"},
+		{Content: "!human", Author: &discordgo.User{ID: "user"}, Type: discordgo.MessageTypeGuildMemberJoin},
+		{Content: "!human", Author: &discordgo.User{ID: "user"}},
+	}
+
+	for _, m := range messages {
+		r.FindAndExecute(nil, "!", "botid", m)
+	}
+
+	if called["human"] != 1 || called["relay"] != 1 {
+		t.Errorf("unexpected calls: %v", called)
+	}
+}
+
+func TestApplicationCommands(t *testing.T) {
+	r := exrouter.New()
+
+	r.On("ping", nil).Desc("pong")
+	r.On("ban", nil).Desc("bans a member").Args(
+		arg.Member("member").Desc("member to ban"),
+		arg.Duration("for").Desc("how long to ban for").Optional(),
+	).Flag(dgrouter.NewBoolFlag("silent", "s").Desc("don't announce the ban"))
+
+	tag := r.On("tag", nil).Desc("manage tags")
+	tag.On("create", nil).Desc("creates a tag").Args(
+		arg.String("name").Desc("tag name").Autocomplete(arg.Suggestions(func(ctx *exrouter.Context) []string { return nil })),
+		arg.Rest("content").Desc("tag text"),
+	)
+	tag.On("alias", nil).Desc("manage aliases").On("add", nil).Desc("adds an alias")
+
+	r.On("Invalid", nil).Desc("uppercase names are not allowed")
+	r.On("nodesc", nil)
+
+	cmds, err := r.ApplicationCommands()
+	errs, ok := err.(exrouter.CommandErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expected 2 command errors, got %v", err)
+	}
+	if len(cmds) != 3 {
+		t.Fatalf("expected 3 commands, got %d", len(cmds))
+	}
+
+	ban := cmds[1]
+	if len(ban.Options) != 3 {
+		t.Fatalf("expected 3 ban options, got %d", len(ban.Options))
+	}
+	if o := ban.Options[0]; o.Type != discordgo.ApplicationCommandOptionUser || !o.Required {
+		t.Errorf("unexpected member option: %+v", o)
+	}
+	if o := ban.Options[1]; o.Type != discordgo.ApplicationCommandOptionString || o.Required {
+		t.Errorf("unexpected duration option: %+v", o)
+	}
+	if o := ban.Options[2]; o.Type != discordgo.ApplicationCommandOptionBoolean || o.Name != "silent" {
+		t.Errorf("unexpected flag option: %+v", o)
+	}
+
+	sub := cmds[2].Options
+	if len(sub) != 2 || sub[0].Type != discordgo.ApplicationCommandOptionSubCommand ||
+		sub[1].Type != discordgo.ApplicationCommandOptionSubCommandGroup || len(sub[1].Options) != 1 {
+		t.Errorf("unexpected subcommands: %+v", sub)
+	}
+	if opts := sub[0].Options; !opts[0].Autocomplete || opts[1].Autocomplete {
+		t.Errorf("expected only the tag name to be autocompleted")
+	}
+
+	tag.On("alias", nil).On("add", nil).On("deep", nil).Desc("too deep")
+	if _, err := exrouter.ApplicationCommand(tag); !errors.Is(err, exrouter.ErrCommandTooDeep) {
+		t.Errorf("expected ErrCommandTooDeep, got %v", err)
+	}
+
+	// Middleware does not give command groups a handler
+	r = exrouter.New()
+	r.Use(func(fn exrouter.HandlerFunc) exrouter.HandlerFunc { return fn })
+	role := r.On("role", nil)
+	role.Desc("manage roles")
+	role.On("add", func(ctx *exrouter.Context) {}).Desc("adds a role")
+	role.On("remove", func(ctx *exrouter.Context) {}).Desc("removes a role")
+	if _, err := exrouter.ApplicationCommand(role.Route); err != nil {
+		t.Errorf("unexpected error exporting a group with middleware: %v", err)
+	}
+}
+
+func TestInteractions(t *testing.T) {
+	r := exrouter.New()
+
+	var middleware, called int
+	tag := r.On("tag", nil)
+	tag.Use(func(fn exrouter.HandlerFunc) exrouter.HandlerFunc {
+		return func(ctx *exrouter.Context) {
+			middleware++
+			fn(ctx)
+		}
+	})
+	tag.On("show", func(ctx *exrouter.Context) {
+		called++
+		if ctx.Interaction == nil || ctx.Msg.Author.ID != "user" {
+			t.Errorf("expected the interaction user on the context")
+		}
+		if ctx.Params.String("name") != "cats" || ctx.Params.Int("page") != 2 {
+			t.Errorf("unexpected params: %v", ctx.Params)
+		}
+		if !ctx.Flags.Bool("raw") {
+			t.Errorf("expected the raw flag to be set")
+		}
+		if ctx.Args.Get(0) != "tag show" || ctx.Args.Get(2) != "2" {
+			t.Errorf("unexpected args: %v", ctx.Args)
+		}
+	}).Args(arg.String("name"), arg.Int("page").Optional()).Flag(dgrouter.NewBoolFlag("raw", ""))
+
+	d := exrouter.NewDispatcher(r, nil)
+	err := d.DispatchInteraction(nil, &discordgo.Interaction{
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: "guild",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "user"}},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "tag",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+				Name: "show",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "name", Type: discordgo.ApplicationCommandOptionString, Value: "cats"},
+					{Name: "page", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(2)},
+					{Name: "raw", Type: discordgo.ApplicationCommandOptionBoolean, Value: true},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if called != 1 || middleware != 1 {
+		t.Errorf("expected the handler and middleware to be called once, got %d and %d", called, middleware)
+	}
+}
+
+func TestInteractionArguments(t *testing.T) {
+	api := &messageServer{}
+	s, done := newTestSession(api)
+	defer done()
+
+	r := exrouter.New()
+	days := arg.Int("days").Optional()
+	parseInt := days.Parse
+	days.Parse = func(ctx interface{}, raw string) (interface{}, error) {
+		v, err := parseInt(ctx, raw)
+		if err == nil && v.(int) > 7 {
+			return nil, errors.New("days must be at most 7")
+		}
+		return v, err
+	}
+
+	var called int
+	r.On("ban", func(ctx *exrouter.Context) {
+		called++
+		if ctx.Args.Get(2) != "" || ctx.Args.Get(3) != "spam" {
+			t.Errorf("expected the omitted argument to keep its position, got %q", ctx.Args)
+		}
+	}).Args(arg.String("user"), days, arg.String("reason").Optional())
+
+	d := exrouter.NewDispatcher(r, nil)
+	ban := func(options ...*discordgo.ApplicationCommandInteractionDataOption) error {
+		return d.DispatchInteraction(s, &discordgo.Interaction{
+			ID:    "interaction",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommand,
+			User:  &discordgo.User{ID: "user"},
+			Data:  discordgo.ApplicationCommandInteractionData{Name: "ban", Options: options},
+		})
+	}
+	user := &discordgo.ApplicationCommandInteractionDataOption{Name: "user", Type: discordgo.ApplicationCommandOptionString, Value: "bob"}
+
+	if err := ban(user, &discordgo.ApplicationCommandInteractionDataOption{Name: "reason", Type: discordgo.ApplicationCommandOptionString, Value: "spam"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ban(user, &discordgo.ApplicationCommandInteractionDataOption{Name: "days", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(30)}); err == nil {
+		t.Error("expected the argument's parser to reject the option")
+	}
+	if called != 1 || len(api.requests) != 1 {
+		t.Errorf("expected one call and one error reply, got %d calls and %q", called, api.requests)
+	}
+}
+
+func TestComponents(t *testing.T) {
+	r := exrouter.New()
+
+	var called int
+	r.OnComponent("vote:{poll}:{option}", func(ctx *exrouter.Context) {
+		called++
+		if ctx.Params.String("poll") != "12" || ctx.Params.String("option") != "yes" {
+			t.Errorf("unexpected params: %v", ctx.Params)
+		}
+	})
+	r.OnComponent("colour", func(ctx *exrouter.Context) {
+		called++
+		if ctx.Args.After(1) != "red blue" {
+			t.Errorf("unexpected select menu values: %v", ctx.Args)
+		}
+	})
+
+	d := exrouter.NewDispatcher(r, nil)
+	click := func(customID string, values ...string) error {
+		return d.DispatchInteraction(nil, &discordgo.Interaction{
+			Type: discordgo.InteractionMessageComponent,
+			User: &discordgo.User{ID: "user"},
+			Data: discordgo.MessageComponentInteractionData{CustomID: customID, Values: values},
+		})
+	}
+
+	if err := click("vote:12:yes"); err != nil {
+		t.Error(err)
+	}
+	if err := click("colour", "red", "blue"); err != nil {
+		t.Error(err)
+	}
+	if err := click("vote:12"); err != dgrouter.ErrCouldNotFindRoute {
+		t.Errorf("expected ErrCouldNotFindRoute, got %v", err)
+	}
+	if called != 2 {
+		t.Errorf("expected 2 calls, got %d", called)
+	}
+}
+
+func TestModals(t *testing.T) {
+	r := exrouter.New()
+
+	var called int
+	r.OnModal("appeal:{case}", func(ctx *exrouter.Context) {
+		called++
+		if ctx.Params.String("case") != "7" || ctx.Params.String("reason") != "it was a joke" {
+			t.Errorf("unexpected params: %v", ctx.Params)
+		}
+		if ctx.Params.Int("age") != 20 || ctx.Params.Has("contact") {
+			t.Errorf("unexpected params: %v", ctx.Params)
+		}
+	}).Args(arg.Rest("reason"), arg.Int("age"), arg.String("contact").Optional())
+
+	row := func(id, value string) discordgo.MessageComponent {
+		return &discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			&discordgo.TextInput{CustomID: id, Value: value},
+		}}
+	}
+
+	d := exrouter.NewDispatcher(r, nil)
+	err := d.DispatchInteraction(nil, &discordgo.Interaction{
+		Type: discordgo.InteractionModalSubmit,
+		User: &discordgo.User{ID: "user"},
+		Data: discordgo.ModalSubmitInteractionData{
+			CustomID:   "appeal:7",
+			Components: []discordgo.MessageComponent{row("reason", "it was a joke"), row("age", "20"), row("contact", "")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if called != 1 {
+		t.Errorf("expected 1 call, got %d", called)
+	}
+}
+
+func TestContextMenus(t *testing.T) {
+	r := exrouter.New()
+	r.On("info", nil).Desc("shows information")
+
+	var target string
+	r.OnUserCommand("User Info", func(ctx *exrouter.Context) {
+		target = ctx.TargetUser.ID + " " + ctx.TargetMember.GuildID
+	})
+	r.OnMessageCommand("Bookmark", func(ctx *exrouter.Context) {
+		target = ctx.TargetMessage.Content
+	})
+
+	cmds, err := r.ApplicationCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 3 || cmds[1].Type != discordgo.UserApplicationCommand || cmds[2].Type != discordgo.MessageApplicationCommand {
+		t.Errorf("unexpected commands: %+v", cmds)
+	}
+
+	d := exrouter.NewDispatcher(r, nil)
+	run := func(typ discordgo.ApplicationCommandType, name string) {
+		err := d.DispatchInteraction(nil, &discordgo.Interaction{
+			Type:    discordgo.InteractionApplicationCommand,
+			GuildID: "guild",
+			Member:  &discordgo.Member{User: &discordgo.User{ID: "user"}},
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name:        name,
+				CommandType: typ,
+				TargetID:    "target",
+				Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+					Users:    map[string]*discordgo.User{"target": {ID: "target"}},
+					Members:  map[string]*discordgo.Member{"target": {}},
+					Messages: map[string]*discordgo.Message{"target": {Content: "hello"}},
+				},
+			},
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	run(discordgo.UserApplicationCommand, "User Info")
+	if target != "target guild" {
+		t.Errorf("unexpected user target: %q", target)
+	}
+	run(discordgo.MessageApplicationCommand, "Bookmark")
+	if target != "hello" {
+		t.Errorf("unexpected message target: %q", target)
+	}
+}
+
+func TestReactions(t *testing.T) {
+	r := exrouter.New()
+
+	called := map[string]int{}
+	r.OnReaction("⭐", func(ctx *exrouter.Context) { called["star"]++ })
+	r.OnReaction("⭐", nil).Filter(exrouter.OnMessages("poll"))
+	r.OnReaction("party", func(ctx *exrouter.Context) {
+		called["party"]++
+		if ctx.Msg.Author.ID != "user" || ctx.TargetMessage.ID != "poll" {
+			t.Errorf("unexpected context: %+v %+v", ctx.Msg, ctx.TargetMessage)
+		}
+	})
+	r.OnReaction(exrouter.AnyEmoji, func(ctx *exrouter.Context) { called["any"]++ }).Filter(exrouter.OnMessages("poll"))
+	r.OnReactionRemove("⭐", func(ctx *exrouter.Context) { called["removed"]++ })
+
+	d := exrouter.NewDispatcher(r, nil)
+	d.BotID = "botid"
+	react := func(userID, messageID string, emoji discordgo.Emoji, added bool) {
+		d.DispatchReaction(nil, &discordgo.MessageReaction{
+			UserID:    userID,
+			MessageID: messageID,
+			ChannelID: "channel",
+			Emoji:     emoji,
+		}, nil, added)
+	}
+
+	react("user", "other", discordgo.Emoji{Name: "⭐"}, true)
+	react("user", "poll", discordgo.Emoji{Name: "party", ID: "222222222222222222"}, true)
+	react("botid", "poll", discordgo.Emoji{Name: "⭐"}, true)
+	react("user", "other", discordgo.Emoji{Name: "⭐"}, false)
+
+	if called["star"] != 1 || called["party"] != 1 || called["any"] != 1 || called["removed"] != 1 {
+		t.Errorf("unexpected calls: %v", called)
+	}
+}
+
+type customEvent struct{ n int }
+
+func (e *customEvent) amount() int { return e.n }
+
+type amounted interface{ amount() int }
+
+func TestEvents(t *testing.T) {
+	r := exrouter.New()
+
+	var joined []string
+	r.OnEvent(func(ctx *exrouter.Context, m *discordgo.GuildMemberAdd) {
+		joined = append(joined, ctx.Msg.Author.ID)
+	}).Filter(exrouter.InGuild("guild"))
+
+	var total int
+	r.OnEvent(func(ctx *exrouter.Context, e *customEvent) { total += e.n })
+
+	var all, amounts int
+	r.OnEvent(func(ctx *exrouter.Context, e interface{}) { all++ })
+	r.OnEvent(func(ctx *exrouter.Context, e amounted) { amounts += e.amount() })
+
+	d := exrouter.NewDispatcher(r, nil)
+	member := func(guildID, userID string) *discordgo.GuildMemberAdd {
+		return &discordgo.GuildMemberAdd{Member: &discordgo.Member{GuildID: guildID, User: &discordgo.User{ID: userID}}}
+	}
+	d.DispatchEvent(nil, member("guild", "a"))
+	d.DispatchEvent(nil, member("other", "b"))
+	d.DispatchEvent(nil, &customEvent{2})
+	d.DispatchEvent(nil, &customEvent{3})
+
+	if len(joined) != 1 || joined[0] != "a" {
+		t.Errorf("unexpected members: %v", joined)
+	}
+	if total != 5 || all != 4 || amounts != 5 {
+		t.Errorf("unexpected calls: total %d, all %d, amounts %d", total, all, amounts)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected invalid handler to panic")
+		}
+	}()
+	r.OnEvent(func(e *customEvent) {})
+}
+
+func TestScopes(t *testing.T) {
+	r := exrouter.New()
+
+	var calls int
+	call := func(ctx *exrouter.Context) { calls++ }
+	voice := r.On("voice", nil)
+	voice.Desc("guild only").GuildOnly()
+	voice.On("join", call).Desc("joins")
+	r.On("inbox", call).Desc("dm only").DMOnly()
+	r.On("admin", call).Desc("one guild").InGuilds("home")
+
+	var rejected []error
+	d := exrouter.NewDispatcher(r, exrouter.StaticPrefix("!"))
+	d.OnReject = func(ctx *exrouter.Context, err error) { rejected = append(rejected, err) }
+
+	send := func(content, guildID string) {
+		d.Dispatch(nil, &discordgo.Message{Content: content, GuildID: guildID, Author: &discordgo.User{ID: "user"}})
+	}
+	send("!voice join", "guild")
+	send("!voice join", "")
+	send("!inbox", "")
+	send("!inbox", "guild")
+	send("!admin", "home")
+	send("!admin", "guild")
+
+	want := []error{dgrouter.ErrGuildOnly, dgrouter.ErrDMOnly, dgrouter.ErrGuildNotAllowed}
+	if calls != 3 || len(rejected) != len(want) {
+		t.Fatalf("unexpected calls %d and rejections %v", calls, rejected)
+	}
+	for i, v := range want {
+		if rejected[i] != v {
+			t.Errorf("expected %v, got %v", v, rejected[i])
+		}
+	}
+
+	global, err := r.ApplicationCommands()
+	if err != nil || len(global) != 2 || global[0].DMPermission == nil || *global[0].DMPermission || global[1].DMPermission != nil {
+		t.Errorf("unexpected global commands: %+v %v", global, err)
+	}
+	home, err := r.ApplicationCommandsFor("home")
+	if err != nil || len(home) != 2 || home[1].Name != "admin" {
+		t.Errorf("unexpected guild commands: %+v %v", home, err)
+	}
+}
+
+func TestWorkerPool(t *testing.T) {
+	r := exrouter.New()
+
+	var (
+		mu    sync.Mutex
+		calls = map[string][]int{}
+	)
+	r.On("count", func(ctx *exrouter.Context) {
+		n, _ := strconv.Atoi(ctx.Args.Get(1))
+		mu.Lock()
+		calls[ctx.Msg.Author.ID] = append(calls[ctx.Msg.Author.ID], n)
+		mu.Unlock()
+	})
+
+	p := exrouter.NewWorkerPool(exrouter.NewDispatcher(r, exrouter.StaticPrefix("!")), 4, 8)
+	for i := 0; i < 50; i++ {
+		for _, user := range []string{"a", "b", "c"} {
+			p.Dispatch(nil, &discordgo.Message{Content: "!count " + strconv.Itoa(i), Author: &discordgo.User{ID: user}})
+		}
+	}
+	p.Close()
+
+	for user, v := range calls {
+		if len(v) != 50 {
+			t.Errorf("expected 50 calls from %s, got %d", user, len(v))
+		}
+		for i, n := range v {
+			if n != i {
+				t.Errorf("commands from %s ran out of order: %v", user, v)
+				break
+			}
+		}
+	}
+	if err := p.Go("", func() {}); err != exrouter.ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+
+	p = exrouter.NewWorkerPool(nil, 1, 1)
+	p.Overflow = exrouter.OverflowReject
+
+	started, release := make(chan struct{}), make(chan struct{})
+	p.Go("", func() {
+		close(started)
+		<-release
+	})
+	<-started
+	if err := p.Go("", func() {}); err != nil {
+		t.Errorf("expected the command to be queued, got %v", err)
+	}
+	if err := p.Go("", func() {}); err != exrouter.ErrQueueFull {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+	close(release)
+	p.Close()
+
+	// One key can not fill the queue
+	p = exrouter.NewWorkerPool(nil, 1, 8)
+	p.MaxPerKey = 2
+
+	started, release = make(chan struct{}), make(chan struct{})
+	p.Go("a", func() {
+		close(started)
+		<-release
+	})
+	<-started
+	if err := p.Go("a", func() {}); err != nil {
+		t.Errorf("expected the command to be queued, got %v", err)
+	}
+	if err := p.Go("a", func() {}); err != exrouter.ErrKeyQueueFull {
+		t.Errorf("expected ErrKeyQueueFull, got %v", err)
+	}
+	if err := p.Go("b", func() {}); err != nil {
+		t.Errorf("expected other keys to be queued, got %v", err)
+	}
+	close(release)
+	p.Close()
+
+	// Submitters blocked on a full queue can not exceed the limit of their key
+	p = exrouter.NewWorkerPool(nil, 2, 1)
+	p.MaxPerKey = 2
+
+	var running sync.WaitGroup
+	releaseB, releaseC, releaseA := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	running.Add(2)
+	p.Go("b", func() {
+		running.Done()
+		<-releaseB
+	})
+	p.Go("c", func() {
+		running.Done()
+		<-releaseC
+	})
+	running.Wait()
+	p.Go("d", func() {})
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() { errs <- p.Go("a", func() { <-releaseA }) }()
+	}
+	time.Sleep(time.Millisecond * 20)
+
+	// One worker frees up, so one command with the key runs and another is queued
+	close(releaseB)
+	var rejected int
+	for i := 0; i < 3; i++ {
+		if err := <-errs; err == exrouter.ErrKeyQueueFull {
+			rejected++
+		}
+	}
+	close(releaseA)
+	close(releaseC)
+	p.Close()
+	if rejected != 1 {
+		t.Errorf("expected 1 command over the key limit to be rejected, got %d", rejected)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r := exrouter.New()
+	r.Default = r.On("help", nil).Desc("prints help")
+	r.On("tag", nil).On("create", nil)
+
+	opts := exrouter.ResolveOptions{Prefixes: []string{"!"}, BotID: "botid", MentionPrefix: true}
+
+	res, err := r.Resolve(`!tag create name "some text"`, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Route.Name != "create" || len(res.Path) != 2 || res.Prefix != "!" || res.Mention ||
+		res.Args.Get(0) != "tag create" || res.Args.Get(2) != "some text" {
+		t.Errorf("unexpected resolution: %+v", res)
+	}
+
+	res, err = r.Resolve("<@botid> tag", opts)
+	if err != nil || res.Route.Name != "tag" || !res.Mention {
+		t.Errorf("expected a mention to resolve tag, got %+v %v", res, err)
+	}
+
+	res, err = r.Resolve("<@!botid>", opts)
+	if err != nil || res.Route != r.Default || !res.Mention || len(res.Path) != 0 {
+		t.Errorf("expected a mention to resolve the default route, got %+v %v", res, err)
+	}
+
+	for _, v := range []string{"tag", "!missing", "!"} {
+		if _, err := r.Resolve(v, opts); err != dgrouter.ErrCouldNotFindRoute {
+			t.Errorf("expected %q not to resolve, got %v", v, err)
+		}
+	}
+
+	// Params parses the arguments of a resolution without calling the handler
+	var called bool
+	r.On("ban", func(ctx *exrouter.Context) { called = true }).
+		Args(arg.Int("days"), arg.Rest("reason")).
+		Flag(dgrouter.NewBoolFlag("silent", "s"))
+
+	res, err = r.Resolve("!ban 7 -s spam bot", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := res.Context(nil, &discordgo.Message{})
+	if err := res.Params(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Params.Int("days") != 7 || ctx.Params.String("reason") != "spam bot" || !ctx.Flags.Bool("silent") {
+		t.Errorf("unexpected params: %v %v", ctx.Params, ctx.Flags)
+	}
+
+	res, _ = r.Resolve("!ban soon", opts)
+	if err := res.Params(res.Context(nil, &discordgo.Message{})); err == nil {
+		t.Error("expected an error parsing an invalid argument")
+	}
+	if called {
+		t.Error("expected the handler not to be called")
+	}
+}
+
+func TestPipelines(t *testing.T) {
+	api := &messageServer{}
+	s, done := newTestSession(api)
+	defer done()
+
+	r := exrouter.New()
+
+	var said []string
+
+	r.On("search", func(ctx *exrouter.Context) {
+		ctx.Reply("cat 1")
+		ctx.Reply("cat 2")
+	})
+	r.On("first", func(ctx *exrouter.Context) {
+		ctx.Reply(strings.SplitN(ctx.Params.String("lines"), "\n", 2)[0])
+	}).Args(arg.Rest("lines")).Pipe()
+	r.On("poll", func(ctx *exrouter.Context) {
+		said = append(said, "poll: "+ctx.Args.After(1))
+	})
+	r.On("ping", func(ctx *exrouter.Context) {})
+
+	r.On("slow", func(ctx *exrouter.Context) {
+		<-ctx.Context().Done()
+	})
+	var late error
+	r.On("late", func(ctx *exrouter.Context) {
+		<-ctx.Context().Done()
+		_, late = ctx.Reply("too late")
+	}).Pipe()
+	r.On("say", func(ctx *exrouter.Context) {
+		said = append(said, ctx.Params.String("prefix")+": "+ctx.Params.String("text")+" ("+ctx.Input+")")
+	}).Args(arg.String("prefix"), arg.Rest("text")).Pipe()
+
+	d := exrouter.NewDispatcher(r, exrouter.StaticPrefix("!"))
+	d.MaxPipeline = 3
+	send := func(content string) error {
+		return d.Dispatch(s, &discordgo.Message{ChannelID: "channel", Content: content, Author: &discordgo.User{ID: "user"}})
+	}
+
+	if err := send("!search cats | first | say found"); err != nil {
+		t.Fatal(err)
+	}
+	if err := send(`!say quoted "a | b"`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages whose later stages are not commands that accept input are not pipelines
+	if err := send("!poll yes | no"); err != nil {
+		t.Fatal(err)
+	}
+	if err := send("!poll yes | ping"); err != nil {
+		t.Fatal(err)
+	}
+
+	var perr *exrouter.PipelineError
+	if err := send("!search | first | say a | say b"); err != exrouter.ErrPipelineTooLong {
+		t.Errorf("expected ErrPipelineTooLong, got %v", err)
+	}
+	if err := send("!search | first | ping"); !errors.As(err, &perr) || perr.Stage != 3 || perr.Err != exrouter.ErrPipeNotAllowed {
+		t.Errorf("expected ErrPipeNotAllowed for the third command, got %v", err)
+	}
+
+	// A pipeline that runs out of time cancels the context of the running command and drops its later replies
+	d.PipelineTimeout = time.Millisecond * 20
+	if err := send("!slow | say late"); !errors.As(err, &perr) || perr.Stage != 1 || perr.Err != exrouter.ErrPipelineTimeout {
+		t.Errorf("expected ErrPipelineTimeout for the first command, got %v", err)
+	}
+	if err := send("!search | late"); !errors.As(err, &perr) || perr.Stage != 2 || late != exrouter.ErrPipelineTimeout {
+		t.Errorf("expected the reply of the last command to be dropped, got %v and %v", err, late)
+	}
+
+	replies := []string{
+		"error: " + exrouter.ErrPipelineTooLong.Error(),
+		"error: pipeline command 3 (ping): " + exrouter.ErrPipeNotAllowed.Error(),
+		"error: pipeline command 1 (slow): " + exrouter.ErrPipelineTimeout.Error(),
+		"error: pipeline command 2 (late): " + exrouter.ErrPipelineTimeout.Error(),
+	}
+	if len(api.requests) != len(replies) {
+		t.Fatalf("expected the pipeline errors to be sent, got %q", api.requests)
+	}
+	for i, v := range replies {
+		if api.requests[i] != "POST /api/v10/channels/channel/messages "+v {
+			t.Errorf("expected %q to be sent, got %q", v, api.requests[i])
+		}
+	}
+
+	want := []string{"found: cat 1 (cat 1)", "quoted: a | b ()", "poll: yes | no", "poll: yes | ping"}
+	if len(said) != len(want) {
+		t.Fatalf("unexpected output: %q", said)
+	}
+	for i, v := range want {
+		if said[i] != v {
+			t.Errorf("expected %q, got %q", v, said[i])
+		}
+	}
+}
+
+func TestMiddlewareBeforeArguments(t *testing.T) {
+	r := exrouter.New()
+
+	var parsed, called int
+	lookup := dgrouter.NewArgument("member", "member", func(ctx interface{}, raw string) (interface{}, error) {
+		parsed++
+		return raw, nil
+	})
+
+	admin := r.Use(func(next exrouter.HandlerFunc) exrouter.HandlerFunc {
+		return func(ctx *exrouter.Context) {
+			if ctx.Msg.Author.ID == "admin" {
+				next(ctx)
+			}
+		}
+	})
+	admin.On("kick", func(ctx *exrouter.Context) { called++ }).Args(lookup)
+
+	d := exrouter.NewDispatcher(r, exrouter.StaticPrefix("!"))
+	send := func(authorID string) error {
+		return d.Dispatch(nil, &discordgo.Message{Content: "!kick someone", Author: &discordgo.User{ID: authorID}})
+	}
+
+	if err := send("user"); err != nil || parsed != 0 || called != 0 {
+		t.Errorf("expected middleware to stop the command before parsing, got %v %d %d", err, parsed, called)
+	}
+	if err := send("admin"); err != nil || parsed != 1 || called != 1 {
+		t.Errorf("expected the command to be parsed and called, got %v %d %d", err, parsed, called)
+	}
+}
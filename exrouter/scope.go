@@ -0,0 +1,67 @@
+package exrouter
+
+import (
+	"github.com/Necroforger/dgrouter"
+	"github.com/bwmarrin/discordgo"
+)
+
+// RejectFunc is called when a route is used outside of its scope
+//    err : the reason the route was rejected, ex. dgrouter.ErrGuildOnly
+type RejectFunc func(ctx *Context, err error)
+
+// ReplyRejection replies to the user with the reason a route was rejected
+func ReplyRejection(ctx *Context, err error) {
+	ctx.Reply(err)
+}
+
+// CheckScope returns an error if a route can not be used in the channel of this context
+// It can be used to leave routes that can not be used out of help menus
+// The type of the channel is only retrieved if the route is limited to some channel types
+//    rt : route to check, see dgrouter.Route.GuildOnly
+func (c *Context) CheckScope(rt *dgrouter.Route) error {
+	scope := rt.EffectiveScope()
+
+	typ := dgrouter.UnknownChannelType
+	if len(scope.ChannelTypes) > 0 {
+		typ = channelType(c.Ses, c.Msg)
+	}
+	return scope.Check(c.Msg.GuildID, typ)
+}
+
+// checkScope rejects the route of a context if it is used outside of its scope
+func (d *Dispatcher) checkScope(ctx *Context) error {
+	err := ctx.CheckScope(ctx.Route)
+	if err != nil && d.OnReject != nil {
+		d.OnReject(ctx, err)
+	}
+	return err
+}
+
+// channelType returns the type of the channel a message was sent in
+// Messages outside of guilds are assumed to be direct messages if the channel can not be retrieved
+func channelType(s *discordgo.Session, m *discordgo.Message) int {
+	if s != nil {
+		if s.State != nil {
+			if c, err := s.State.Channel(m.ChannelID); err == nil {
+				return int(c.Type)
+			}
+		}
+		if c, err := s.Channel(m.ChannelID); err == nil {
+			return int(c.Type)
+		}
+	}
+	if m.GuildID == "" {
+		return int(discordgo.ChannelTypeDM)
+	}
+	return dgrouter.UnknownChannelType
+}
+
+// dmPermission returns the DM permission of an exported command with the given scope
+// It is nil when the command can be used in direct messages, which is discord's default
+func dmPermission(scope dgrouter.Scope) *bool {
+	if scope.Check("", int(discordgo.ChannelTypeDM)) == nil {
+		return nil
+	}
+	allowed := false
+	return &allowed
+}
@@ -0,0 +1,286 @@
+package exrouter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// SyncAction is a change made to a registered application command
+type SyncAction int
+
+// Sync actions
+const (
+	SyncCreate SyncAction = iota + 1
+	SyncUpdate
+	SyncDelete
+)
+
+func (a SyncAction) String() string {
+	switch a {
+	case SyncCreate:
+		return "create"
+	case SyncUpdate:
+		return "update"
+	case SyncDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// SyncChange is a single change needed to make the registered commands match the exported ones
+type SyncChange struct {
+	Action SyncAction
+
+	// Command is the exported command, or the registered command when it is deleted
+	Command *discordgo.ApplicationCommand
+
+	// Registered is the command currently registered with discord
+	// It is nil when the command is created
+	Registered *discordgo.ApplicationCommand
+}
+
+func (c *SyncChange) String() string {
+	return c.Action.String() + " /" + c.Command.Name
+}
+
+// SyncReport lists the changes made by a sync
+type SyncReport struct {
+	// DryRun is true if the changes were only computed and not applied
+	DryRun bool
+
+	Changes []*SyncChange
+
+	// Unchanged lists the registered commands that already matched their exported definition
+	Unchanged []*discordgo.ApplicationCommand
+}
+
+func (r *SyncReport) String() string {
+	if len(r.Changes) == 0 {
+		return "commands are up to date"
+	}
+	var lines []string
+	for _, v := range r.Changes {
+		lines = append(lines, v.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SyncError is returned when a change could not be applied
+type SyncError struct {
+	Change *SyncChange
+	Err    error
+}
+
+func (e *SyncError) Error() string {
+	return e.Change.String() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
+
+// CommandSync synchronizes exported application commands with the commands registered with discord
+// Only the commands that changed are created, edited or deleted,
+// so unchanged commands keep their IDs and permissions and no rate limits are spent on them.
+//
+// example:
+// sync := exrouter.NewCommandSync(s, s.State.User.ID, "")
+// report, err := sync.SyncRoutes(router)
+type CommandSync struct {
+	Session *discordgo.Session
+	AppID   string
+
+	// GuildID is the guild to register commands in
+	// Commands are registered globally if it is empty
+	GuildID string
+
+	// DryRun computes the changes without applying them
+	DryRun bool
+
+	// KeepUnknown leaves registered commands that were not exported alone instead of deleting them
+	KeepUnknown bool
+}
+
+// NewCommandSync returns a new command sync
+//    s       : session used to call the REST API
+//    appID   : ID of the application, usually the bot's user ID
+//    guildID : guild to register commands in, or empty to register them globally
+func NewCommandSync(s *discordgo.Session, appID, guildID string) *CommandSync {
+	return &CommandSync{
+		Session: s,
+		AppID:   appID,
+		GuildID: guildID,
+	}
+}
+
+// SyncRoutes exports the routes of a router that can be used in the sync's guild and synchronizes them
+// Nothing is synchronized if a route can not be exported,
+// since the commands of the route would otherwise be deleted
+func (c *CommandSync) SyncRoutes(r *Route) (*SyncReport, error) {
+	commands, err := r.ApplicationCommandsFor(c.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	return c.Sync(commands)
+}
+
+// Sync makes the registered commands match the given commands
+// The report contains every change, even if applying one of them fails
+func (c *CommandSync) Sync(commands []*discordgo.ApplicationCommand) (*SyncReport, error) {
+	registered, err := c.Session.ApplicationCommands(c.AppID, c.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := c.Plan(registered, commands)
+	if c.DryRun {
+		return report, nil
+	}
+
+	for _, v := range report.Changes {
+		if err := c.apply(v); err != nil {
+			return report, &SyncError{v, err}
+		}
+	}
+	return report, nil
+}
+
+// Plan compares the registered commands with the exported ones and returns the changes needed
+// It does not call the REST API
+func (c *CommandSync) Plan(registered, commands []*discordgo.ApplicationCommand) *SyncReport {
+	report := &SyncReport{DryRun: c.DryRun}
+
+	existing := map[string]*discordgo.ApplicationCommand{}
+	for _, v := range registered {
+		existing[commandKey(v)] = v
+	}
+
+	for _, v := range commands {
+		key := commandKey(v)
+		old, ok := existing[key]
+		delete(existing, key)
+
+		switch {
+		case !ok:
+			report.Changes = append(report.Changes, &SyncChange{Action: SyncCreate, Command: v})
+		case !commandsEqual(old, v, c.GuildID != ""):
+			report.Changes = append(report.Changes, &SyncChange{Action: SyncUpdate, Command: v, Registered: old})
+		default:
+			report.Unchanged = append(report.Unchanged, old)
+		}
+	}
+
+	if !c.KeepUnknown {
+		// Keep the order of the registered commands so reports are stable
+		for _, v := range registered {
+			if _, ok := existing[commandKey(v)]; ok {
+				report.Changes = append(report.Changes, &SyncChange{Action: SyncDelete, Command: v, Registered: v})
+			}
+		}
+	}
+
+	return report
+}
+
+// apply makes a single change
+func (c *CommandSync) apply(change *SyncChange) error {
+	var err error
+	switch change.Action {
+	case SyncCreate:
+		_, err = c.Session.ApplicationCommandCreate(c.AppID, c.GuildID, change.Command)
+	case SyncUpdate:
+		_, err = c.Session.ApplicationCommandEdit(c.AppID, c.GuildID, change.Registered.ID, change.Command)
+	case SyncDelete:
+		err = c.Session.ApplicationCommandDelete(c.AppID, c.GuildID, change.Registered.ID)
+	}
+	return err
+}
+
+// commandKey identifies a command, since commands of different types can share a name
+func commandKey(cmd *discordgo.ApplicationCommand) string {
+	return fmt.Sprint(commandType(cmd), ":", cmd.Name)
+}
+
+// commandType returns the type of a command, which defaults to a chat command
+func commandType(cmd *discordgo.ApplicationCommand) discordgo.ApplicationCommandType {
+	if cmd.Type == 0 {
+		return discordgo.ChatApplicationCommand
+	}
+	return cmd.Type
+}
+
+// commandsEqual compares the fields of two commands that can be set when registering them
+// Fields left empty are compared using discord's defaults
+//    guild : true if the commands are guild commands, which do not have a DM permission
+func commandsEqual(a, b *discordgo.ApplicationCommand, guild bool) bool {
+	if commandType(a) != commandType(b) || a.Name != b.Name || a.Description != b.Description {
+		return false
+	}
+	if !guild && boolOr(a.DMPermission, true) != boolOr(b.DMPermission, true) {
+		return false
+	}
+	if boolOr(a.NSFW, false) != boolOr(b.NSFW, false) {
+		return false
+	}
+	if (a.DefaultMemberPermissions == nil) != (b.DefaultMemberPermissions == nil) ||
+		a.DefaultMemberPermissions != nil && *a.DefaultMemberPermissions != *b.DefaultMemberPermissions {
+		return false
+	}
+	return optionsEqual(a.Options, b.Options)
+}
+
+// optionsEqual compares two lists of options, including their suboptions
+func optionsEqual(a, b []*discordgo.ApplicationCommandOption) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		if x.Type != y.Type || x.Name != y.Name || x.Description != y.Description ||
+			x.Required != y.Required || x.Autocomplete != y.Autocomplete ||
+			x.MaxValue != y.MaxValue || x.MaxLength != y.MaxLength {
+			return false
+		}
+		if fmt.Sprint(x.ChannelTypes) != fmt.Sprint(y.ChannelTypes) ||
+			optionalFloat(x.MinValue) != optionalFloat(y.MinValue) || optionalInt(x.MinLength) != optionalInt(y.MinLength) {
+			return false
+		}
+		if len(x.Choices) != len(y.Choices) {
+			return false
+		}
+		for j := range x.Choices {
+			// Choice values are compared as text, since numbers are decoded as float64
+			if x.Choices[j].Name != y.Choices[j].Name || fmt.Sprint(x.Choices[j].Value) != fmt.Sprint(y.Choices[j].Value) {
+				return false
+			}
+		}
+		if !optionsEqual(x.Options, y.Options) {
+			return false
+		}
+	}
+	return true
+}
+
+func boolOr(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+	return *b
+}
+
+func optionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return fmt.Sprint(*f)
+}
+
+func optionalInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return fmt.Sprint(*n)
+}
@@ -0,0 +1,357 @@
+package exrouter_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Necroforger/dgrouter"
+	"github.com/Necroforger/dgrouter/exrouter"
+	"github.com/Necroforger/dgrouter/exrouter/arg"
+	"github.com/bwmarrin/discordgo"
+)
+
+// commandServer is a stand-in for the application command endpoints of the discord REST API
+type commandServer struct {
+	mu       sync.Mutex
+	commands []*discordgo.ApplicationCommand
+	requests []string
+}
+
+func (c *commandServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = append(c.requests, r.Method+" "+r.URL.Path)
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(c.commands)
+	case "POST":
+		var cmd discordgo.ApplicationCommand
+		json.NewDecoder(r.Body).Decode(&cmd)
+		cmd.ID = cmd.Name + "-id"
+		c.commands = append(c.commands, &cmd)
+		json.NewEncoder(w).Encode(cmd)
+	case "PATCH", "DELETE":
+		for i, v := range c.commands {
+			if v.ID != id {
+				continue
+			}
+			if r.Method == "DELETE" {
+				c.commands = append(c.commands[:i], c.commands[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			var cmd discordgo.ApplicationCommand
+			json.NewDecoder(r.Body).Decode(&cmd)
+			cmd.ID = id
+			c.commands[i] = &cmd
+			json.NewEncoder(w).Encode(cmd)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// rewriteTransport sends every request to a test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestCommandSync(t *testing.T) {
+	api := &commandServer{commands: []*discordgo.ApplicationCommand{
+		{ID: "ping-id", Type: discordgo.ChatApplicationCommand, Name: "ping", Description: "pong"},
+		{ID: "avatar-id", Type: discordgo.ChatApplicationCommand, Name: "avatar", Description: "old description"},
+		{ID: "removed-id", Type: discordgo.ChatApplicationCommand, Name: "removed", Description: "no longer exported"},
+	}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	s, _ := discordgo.New("Bot token")
+	s.Client = &http.Client{Transport: rewriteTransport{target}}
+
+	r := exrouter.New()
+	r.On("ping", nil).Desc("pong")
+	r.On("avatar", nil).Desc("returns the user's avatar")
+	r.On("say", nil).Desc("repeats a message")
+
+	sync := exrouter.NewCommandSync(s, "app", "")
+	sync.DryRun = true
+	report, err := sync.SyncRoutes(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := report.String(); got != "update /avatar\ncreate /say\ndelete /removed" {
+		t.Errorf("unexpected dry run report:\n%s", got)
+	}
+	if len(api.requests) != 1 {
+		t.Errorf("expected a dry run to only list commands, got %v", api.requests)
+	}
+
+	api.requests = nil
+	sync.DryRun = false
+	if _, err := sync.SyncRoutes(r); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"GET /api/v10/applications/app/commands",
+		"PATCH /api/v10/applications/app/commands/avatar-id",
+		"POST /api/v10/applications/app/commands",
+		"DELETE /api/v10/applications/app/commands/removed-id",
+	}
+	if strings.Join(api.requests, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected requests:\n%s", strings.Join(api.requests, "\n"))
+	}
+
+	report, err = sync.SyncRoutes(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 0 || len(report.Unchanged) != 3 {
+		t.Errorf("expected the commands to be up to date, got:\n%s", report)
+	}
+}
+
+// responseServer is a stand-in for the interaction callback endpoint of the discord REST API
+type responseServer struct {
+	mu        sync.Mutex
+	path      string
+	responses []*discordgo.InteractionResponse
+}
+
+func (c *responseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var resp discordgo.InteractionResponse
+	json.NewDecoder(r.Body).Decode(&resp)
+	c.path = r.URL.Path
+	c.responses = append(c.responses, &resp)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestAutocomplete(t *testing.T) {
+	api := &responseServer{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	s, _ := discordgo.New("Bot token")
+	s.Client = &http.Client{Transport: rewriteTransport{target}}
+
+	sides := func(ctx *exrouter.Context) []string { return []string{"6", "12", "d8", "20", "100"} }
+	r := exrouter.New()
+	r.On("roll", nil).Desc("rolls a die").Args(
+		arg.Int("sides").Desc("number of sides").Autocomplete(arg.Suggestions(sides)),
+		arg.String("label").Desc("label of the roll").Optional().Autocomplete(arg.Suggestions(sides)),
+	)
+
+	d := exrouter.NewDispatcher(r, nil)
+	autocomplete := func(focused string, value interface{}) []*discordgo.ApplicationCommandOptionChoice {
+		api.responses = nil
+		err := d.DispatchInteraction(s, &discordgo.Interaction{
+			ID:    "interaction",
+			Token: "token",
+			Type:  discordgo.InteractionApplicationCommandAutocomplete,
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "roll",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: focused, Type: discordgo.ApplicationCommandOptionString, Value: value, Focused: true},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(api.responses) != 1 || api.responses[0].Type != discordgo.InteractionApplicationCommandAutocompleteResult {
+			t.Fatalf("expected one autocomplete result, got %+v", api.responses)
+		}
+		if api.path != "/api/v10/interactions/interaction/token/callback" {
+			t.Errorf("unexpected callback path: %s", api.path)
+		}
+		return api.responses[0].Data.Choices
+	}
+
+	// Integer options only receive choices that are integers
+	choices := autocomplete("sides", "1")
+	if len(choices) != 2 || choices[0].Name != "12" || choices[0].Value != float64(12) || choices[1].Value != float64(100) {
+		t.Errorf("unexpected integer choices: %+v", choices)
+	}
+
+	choices = autocomplete("label", "d")
+	if len(choices) != 1 || choices[0].Value != "d8" {
+		t.Errorf("unexpected string choices: %+v", choices)
+	}
+}
+
+func TestReactionTarget(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		json.NewEncoder(w).Encode(&discordgo.Message{ID: "poll", ChannelID: "channel", Content: "vote", Author: &discordgo.User{ID: "botid"}})
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	s, _ := discordgo.New("Bot token")
+	s.Client = &http.Client{Transport: rewriteTransport{target}}
+	s.State = discordgo.NewState()
+	s.State.User = &discordgo.User{ID: "botid"}
+
+	r := exrouter.New()
+	var content string
+	r.OnReaction("⭐", func(ctx *exrouter.Context) { content = ctx.TargetMessage.Content }).Filter(exrouter.OnMessages("poll"))
+	r.OnReaction("👍", func(ctx *exrouter.Context) { content = ctx.TargetMessage.Content }).Filter(exrouter.OwnMessages)
+
+	d := exrouter.NewDispatcher(r, nil)
+	react := func(messageID, emoji string) {
+		requests, content = nil, ""
+		d.DispatchReaction(s, &discordgo.MessageReaction{
+			UserID:    "user",
+			MessageID: messageID,
+			ChannelID: "channel",
+			Emoji:     discordgo.Emoji{Name: emoji},
+		}, nil, true)
+	}
+
+	// Filters that only use the message ID do not retrieve the message
+	react("other", "⭐")
+	if len(requests) != 0 {
+		t.Errorf("expected no requests for a filtered reaction, got %v", requests)
+	}
+
+	react("poll", "⭐")
+	if len(requests) != 1 || requests[0] != "/api/v10/channels/channel/messages/poll" || content != "vote" {
+		t.Errorf("expected the message to be retrieved once, got %v %q", requests, content)
+	}
+
+	react("poll", "👍")
+	if len(requests) != 1 || content != "vote" {
+		t.Errorf("expected OwnMessages to retrieve the message once, got %v %q", requests, content)
+	}
+}
+
+func TestReplyTracker(t *testing.T) {
+	api := &messageServer{}
+	s, done := newTestSession(api)
+	defer done()
+
+	r := exrouter.New()
+	r.On("count", func(ctx *exrouter.Context) {
+		for i := 1; i <= ctx.Params.Int("n"); i++ {
+			ctx.Reply(i)
+		}
+	}).Args(arg.Int("n"))
+
+	d := exrouter.NewDispatcher(r, exrouter.StaticPrefix("!"))
+	d.Replies = exrouter.NewReplyTracker(0)
+	send := func(content string) {
+		d.Dispatch(s, &discordgo.Message{ID: "command", ChannelID: "channel", Content: content, Author: &discordgo.User{ID: "user"}})
+	}
+
+	send("!count 3")
+	send("!count 1")
+	send("!count 2")
+	want := []string{
+		"POST /api/v10/channels/channel/messages 1",
+		"POST /api/v10/channels/channel/messages 2",
+		"POST /api/v10/channels/channel/messages 3",
+		"PATCH /api/v10/channels/channel/messages/reply1 1",
+		"DELETE /api/v10/channels/channel/messages/reply2 ",
+		"DELETE /api/v10/channels/channel/messages/reply3 ",
+		"PATCH /api/v10/channels/channel/messages/reply1 1",
+		"POST /api/v10/channels/channel/messages 2",
+	}
+	if len(api.requests) != len(want) {
+		t.Fatalf("unexpected requests: %q", api.requests)
+	}
+	for i, v := range want {
+		if api.requests[i] != v {
+			t.Errorf("expected %q, got %q", v, api.requests[i])
+		}
+	}
+}
+
+// messageServer is a stand-in for the message endpoints of the discord REST API
+// Every request is recorded as its method, path and message content
+type messageServer struct {
+	mu       sync.Mutex
+	requests []string
+	sent     int
+}
+
+func (c *messageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var body struct{ Content *string }
+	json.NewDecoder(r.Body).Decode(&body)
+	content := ""
+	if body.Content != nil {
+		content = *body.Content
+	}
+	c.requests = append(c.requests, r.Method+" "+r.URL.Path+" "+content)
+
+	channelID := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/v10/channels/"), "/", 2)[0]
+	switch r.Method {
+	case "POST":
+		c.sent++
+		json.NewEncoder(w).Encode(&discordgo.Message{ID: "reply" + strconv.Itoa(c.sent), ChannelID: channelID, Content: content})
+	case "PATCH":
+		json.NewEncoder(w).Encode(&discordgo.Message{ID: r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ChannelID: channelID, Content: content})
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// newTestSession returns a session whose requests are sent to a test server
+func newTestSession(h http.Handler) (*discordgo.Session, func()) {
+	srv := httptest.NewServer(h)
+	target, _ := url.Parse(srv.URL)
+	s, _ := discordgo.New("Bot token")
+	s.Client = &http.Client{Transport: rewriteTransport{target}}
+	return s, srv.Close
+}
+
+func TestUnclosedQuotes(t *testing.T) {
+	api := &messageServer{}
+	s, done := newTestSession(api)
+	defer done()
+
+	r := exrouter.New()
+	var called bool
+	r.On("ban", func(ctx *exrouter.Context) { called = true }).Args(arg.String("user"), arg.Rest("reason"))
+
+	d := exrouter.NewDispatcher(r, exrouter.StaticPrefix("!"))
+	err := d.Dispatch(s, &discordgo.Message{ChannelID: "channel", Content: `!ban user "spam bot`, Author: &discordgo.User{ID: "user"}})
+	if _, ok := err.(*dgrouter.LexError); !ok || called {
+		t.Errorf("expected a LexError without calling the handler, got %v", err)
+	}
+	if len(api.requests) != 1 || !strings.HasPrefix(api.requests[0], "POST /api/v10/channels/channel/messages error: ") ||
+		!strings.Contains(api.requests[0], "usage: `!ban <user> <reason...>`") {
+		t.Errorf("expected the error to be sent with the usage, got %q", api.requests)
+	}
+
+	// Text that does not name a route before the quote is not a command
+	api.requests = nil
+	if err := d.Dispatch(s, &discordgo.Message{ChannelID: "channel", Content: `!"ban`, Author: &discordgo.User{ID: "user"}}); err != dgrouter.ErrCouldNotFindRoute {
+		t.Errorf("expected ErrCouldNotFindRoute, got %v", err)
+	}
+	if len(api.requests) != 0 {
+		t.Errorf("expected no replies, got %q", api.requests)
+	}
+}
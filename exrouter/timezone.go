@@ -0,0 +1,71 @@
+package exrouter
+
+import (
+	"sync"
+	"time"
+)
+
+// TimezoneFunc returns the timezone of the author of a command
+type TimezoneFunc func(ctx *Context) *time.Location
+
+// DefaultTimezone finds the timezone of contexts that do not have a Location set
+// It returns UTC unless it is replaced, ex. with the Lookup method of a Timezones store
+var DefaultTimezone TimezoneFunc = func(ctx *Context) *time.Location {
+	return time.UTC
+}
+
+// Timezones stores the timezones of users and guilds
+// A user's timezone takes priority over their guild's timezone
+type Timezones struct {
+	mu     sync.RWMutex
+	users  map[string]*time.Location
+	guilds map[string]*time.Location
+
+	// Default is used when neither the user nor guild have a timezone set
+	Default *time.Location
+}
+
+// NewTimezones returns a new timezone store
+//    def : timezone used when no other timezone is found
+func NewTimezones(def *time.Location) *Timezones {
+	return &Timezones{
+		users:   map[string]*time.Location{},
+		guilds:  map[string]*time.Location{},
+		Default: def,
+	}
+}
+
+// SetUser sets the timezone of a user
+func (t *Timezones) SetUser(userID string, loc *time.Location) {
+	t.mu.Lock()
+	t.users[userID] = loc
+	t.mu.Unlock()
+}
+
+// SetGuild sets the timezone of a guild
+func (t *Timezones) SetGuild(guildID string, loc *time.Location) {
+	t.mu.Lock()
+	t.guilds[guildID] = loc
+	t.mu.Unlock()
+}
+
+// Lookup returns the timezone of the context's author, their guild, or the default timezone
+func (t *Timezones) Lookup(ctx *Context) *time.Location {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if ctx.Msg != nil && ctx.Msg.Author != nil {
+		if loc, ok := t.users[ctx.Msg.Author.ID]; ok {
+			return loc
+		}
+	}
+	if ctx.Msg != nil {
+		if loc, ok := t.guilds[ctx.Msg.GuildID]; ok {
+			return loc
+		}
+	}
+	if t.Default != nil {
+		return t.Default
+	}
+	return time.UTC
+}
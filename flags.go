@@ -0,0 +1,222 @@
+package dgrouter
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Flag errors
+var (
+	ErrUnknownFlag      = errors.New("unknown flag")
+	ErrFlagMissingValue = errors.New("flag requires a value")
+)
+
+// Flag describes a flag accepted by a route
+// ex. --days 7, -d 7, --days=7
+type Flag struct {
+	// Name is the long name of the flag, used as --name
+	Name string
+
+	// Short is the optional short name of the flag, used as -n
+	Short string
+
+	Description string
+
+	// Bool flags do not take a value
+	Bool bool
+}
+
+// NewFlag returns a flag that takes a value
+//    name  : long name of the flag
+//    short : short name of the flag, can be left empty
+func NewFlag(name, short string) *Flag {
+	return &Flag{
+		Name:  name,
+		Short: short,
+	}
+}
+
+// NewBoolFlag returns a flag that does not take a value
+//    name  : long name of the flag
+//    short : short name of the flag, can be left empty
+func NewBoolFlag(name, short string) *Flag {
+	return &Flag{
+		Name:  name,
+		Short: short,
+		Bool:  true,
+	}
+}
+
+// Desc sets this flag's description
+func (f *Flag) Desc(description string) *Flag {
+	f.Description = description
+	return f
+}
+
+// String returns the usage string of the flag
+func (f *Flag) String() string {
+	if f.Bool {
+		return "[--" + f.Name + "]"
+	}
+	return "[--" + f.Name + " value]"
+}
+
+// FlagError is returned when a flag can not be parsed
+type FlagError struct {
+	Name string
+	Err  error
+}
+
+func (e *FlagError) Error() string {
+	return e.Name + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FlagError) Unwrap() error {
+	return e.Err
+}
+
+// Flag declares flags accepted by this route
+// Flags are separated from the positional arguments before the handler is called
+func (r *Route) Flag(flags ...*Flag) *Route {
+	r.Flags = append(r.Flags, flags...)
+	return r
+}
+
+// ParseFlags separates flags from positional arguments
+// It returns the positional arguments and a map of flag names to their values.
+// Arguments after a "--" terminator are always positional.
+// Parsing continues after an error so that every flag is collected, and the first error is returned.
+//    args  : arguments to parse
+//    flags : flags that are accepted. If empty, any flag is accepted
+//            and values must be supplied in the form --name=value
+func ParseFlags(args []string, flags []*Flag) ([]string, map[string][]string, error) {
+	tokens := make([]Token, len(args))
+	for i, v := range args {
+		tokens[i].Value = v
+	}
+
+	positional, values, err := ParseFlagTokens(tokens, flags)
+
+	var pargs []string
+	for _, v := range positional {
+		pargs = append(pargs, v.Value)
+	}
+	return pargs, values, err
+}
+
+// ParseFlagTokens separates flags from positional tokens
+// It is the same as ParseFlags, but keeps the offsets of the positional arguments
+//    tokens : tokens to parse, see LexTokens
+//    flags  : flags that are accepted
+func ParseFlagTokens(tokens []Token, flags []*Flag) ([]Token, map[string][]string, error) {
+	var (
+		positional []Token
+		firstErr   error
+		values     = map[string][]string{}
+	)
+
+	fail := func(name string, err error) {
+		if firstErr == nil {
+			firstErr = &FlagError{name, err}
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		a := tokens[i].Value
+		if a == "--" {
+			positional = append(positional, tokens[i+1:]...)
+			break
+		}
+		if !isFlag(a) {
+			positional = append(positional, tokens[i])
+			continue
+		}
+
+		long := strings.HasPrefix(a, "--")
+		name := strings.TrimLeft(a, "-")
+		value, hasValue := "", false
+		if n := strings.IndexByte(name, '='); n >= 0 {
+			name, value, hasValue = name[:n], name[n+1:], true
+		}
+
+		if len(flags) == 0 {
+			if !hasValue {
+				value = "true"
+			}
+			values[name] = append(values[name], value)
+			continue
+		}
+
+		f := findFlag(flags, name, long)
+
+		// Expand grouped short boolean flags, ex. -abc
+		if f == nil && !long && !hasValue {
+			if group, ok := findGroup(flags, name); ok {
+				for _, g := range group {
+					values[g.Name] = append(values[g.Name], "true")
+				}
+				continue
+			}
+		}
+
+		if f == nil {
+			fail(name, ErrUnknownFlag)
+			continue
+		}
+
+		if !hasValue {
+			switch {
+			case f.Bool:
+				value = "true"
+			case i+1 < len(tokens) && !isFlag(tokens[i+1].Value):
+				i++
+				value = tokens[i].Value
+			default:
+				fail(f.Name, ErrFlagMissingValue)
+				continue
+			}
+		}
+
+		values[f.Name] = append(values[f.Name], value)
+	}
+
+	return positional, values, firstErr
+}
+
+// isFlag returns true if the argument is a flag rather than a value
+// Negative numbers are not considered flags
+func isFlag(a string) bool {
+	if len(a) < 2 || a[0] != '-' || a == "--" {
+		return false
+	}
+	_, err := strconv.ParseFloat(a, 64)
+	return err != nil
+}
+
+func findFlag(flags []*Flag, name string, long bool) *Flag {
+	for _, f := range flags {
+		if long && f.Name == name || !long && f.Short == name {
+			return f
+		}
+	}
+	// Allow long flags to be written with a single dash
+	if !long {
+		return findFlag(flags, name, true)
+	}
+	return nil
+}
+
+// findGroup returns the flags for a group of short boolean flags
+func findGroup(flags []*Flag, group string) ([]*Flag, bool) {
+	var found []*Flag
+	for _, c := range group {
+		f := findFlag(flags, string(c), false)
+		if f == nil || !f.Bool {
+			return nil, false
+		}
+		found = append(found, f)
+	}
+	return found, true
+}
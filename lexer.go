@@ -0,0 +1,237 @@
+package dgrouter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Lexer errors
+var (
+	ErrUnclosedQuote     = errors.New("unclosed quote")
+	ErrUnclosedCodeBlock = errors.New("unclosed code block")
+)
+
+// LexError is returned when a command can not be split into arguments
+type LexError struct {
+	// Offset is the byte offset of the unclosed quote or code block
+	Offset int
+	Err    error
+}
+
+func (e *LexError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Err, e.Offset)
+}
+
+// Unwrap returns the underlying error
+func (e *LexError) Unwrap() error {
+	return e.Err
+}
+
+// quotes maps opening quote characters to the characters that close them
+// Phones often send typographic quotes, which are treated the same as regular quotes
+var quotes = map[rune]string{
+	'"':  `"`,
+	'\'': `'`,
+	'“':  "”“",
+	'”':  "”“",
+	'‘':  "’",
+}
+
+// Token is a single argument produced by the lexer
+type Token struct {
+	// Value is the text of the argument with its quotes and escapes removed
+	// Code spans keep their backticks
+	Value string
+
+	// Code is true if the argument is a code block or inline code span
+	Code bool
+
+	// Lang is the language tag of a code block, ex. go for ```go
+	Lang string
+
+	// Body is the contents of a code span without its backticks and language tag
+	Body string
+
+	// Start and End are the byte offsets of the argument in the lexed content
+	// They include any quotes or backticks surrounding the argument
+	Start, End int
+}
+
+// Lex splits a command into arguments
+// See LexTokens for the rules used to split arguments
+//    content : text of the command, not including the prefix
+func Lex(content string) ([]string, error) {
+	tokens, err := LexTokens(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	for _, v := range tokens {
+		args = append(args, v.Value)
+	}
+	return args, nil
+}
+
+// LexTokens splits a command into tokens
+// Arguments are separated by any amount of whitespace, including newlines.
+// Quotes group text into a single argument, but only when they start an argument or follow
+// the = of a flag, ex. --reason="spam bot", so apostrophes in words like "it's" are kept as they are.
+// A backslash escapes a following quote, backtick, backslash or whitespace character.
+// Code blocks and inline code spans are kept verbatim as a single argument.
+// An unclosed quote or code block results in a *LexError.
+//    content : text of the command, not including the prefix
+func LexTokens(content string) ([]Token, error) {
+	var (
+		tokens  []Token
+		buf     strings.Builder
+		code    *Token
+		inToken bool
+		start   int
+	)
+
+	// write appends text to the current token
+	// A code span followed by other text is no longer treated as code
+	write := func(s string) {
+		buf.WriteString(s)
+		inToken = true
+		code = nil
+	}
+
+	// emit adds the current token to the list of tokens
+	emit := func(end int) {
+		t := Token{Value: buf.String()}
+		if code != nil {
+			t = *code
+		}
+		t.Start, t.End = start, end
+		tokens = append(tokens, t)
+		buf.Reset()
+		inToken = false
+		code = nil
+	}
+
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		if !inToken {
+			start = i
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			if inToken {
+				emit(i)
+			}
+			i += size
+
+		case r == '\\':
+			if next, n := utf8.DecodeRuneInString(content[i+size:]); n > 0 && isEscapable(next) {
+				write(string(next))
+				i += size + n
+			} else {
+				write(string(r))
+				i += size
+			}
+
+		case !inToken && r == '`':
+			end, t, err := lexCode(content, i)
+			if err != nil {
+				return nil, err
+			}
+			write(t.Value)
+			code = &t
+			i = end
+
+		case quotes[r] != "" && (!inToken || content[i-1] == '=' && strings.HasPrefix(content[start:i], "-")):
+			var quoted strings.Builder
+			end, err := lexQuoted(content, i, &quoted)
+			if err != nil {
+				return nil, err
+			}
+			write(quoted.String())
+			i = end
+
+		default:
+			write(string(r))
+			i += size
+		}
+	}
+
+	if inToken {
+		emit(len(content))
+	}
+
+	return tokens, nil
+}
+
+// lexCode reads the code block or inline code span starting at offset start
+// and returns the offset after its closing backticks
+func lexCode(content string, start int) (int, Token, error) {
+	fence := "`"
+	if strings.HasPrefix(content[start:], "```") {
+		fence = "```"
+	}
+
+	end := strings.Index(content[start+len(fence):], fence)
+	if end < 0 {
+		return 0, Token{}, &LexError{start, ErrUnclosedCodeBlock}
+	}
+	end += start + len(fence)*2
+
+	t := Token{Value: content[start:end], Code: true}
+	t.Lang, t.Body, _ = ParseCode(t.Value)
+	return end, t, nil
+}
+
+// ParseCode returns the language tag and body of a code block or inline code span
+// A code block only has a language tag if it is followed by a newline, ex. ```go\n
+// The newline before the closing backticks of a code block is not included in the body
+//    s : code block including its backticks
+func ParseCode(s string) (lang, body string, ok bool) {
+	if strings.HasPrefix(s, "```") && strings.HasSuffix(s, "```") && len(s) >= 6 {
+		body = s[3 : len(s)-3]
+		if n := strings.IndexByte(body, '\n'); n >= 0 && !strings.ContainsAny(body[:n], " \t`") {
+			lang, body = body[:n], body[n+1:]
+		}
+		return lang, strings.TrimSuffix(body, "\n"), true
+	}
+	if strings.HasPrefix(s, "`") && strings.HasSuffix(s, "`") && len(s) >= 2 {
+		return "", s[1 : len(s)-1], true
+	}
+	return "", "", false
+}
+
+// lexQuoted writes the quoted text starting at offset start to buf
+// and returns the offset after the closing quote
+func lexQuoted(content string, start int, buf *strings.Builder) (int, error) {
+	open, size := utf8.DecodeRuneInString(content[start:])
+	closers := quotes[open]
+
+	for i := start + size; i < len(content); {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		switch {
+		case strings.ContainsRune(closers, r):
+			return i + size, nil
+		case r == '\\':
+			if next, n := utf8.DecodeRuneInString(content[i+size:]); n > 0 && (next == '\\' || strings.ContainsRune(closers, next)) {
+				buf.WriteRune(next)
+				i += size + n
+				continue
+			}
+			fallthrough
+		default:
+			buf.WriteRune(r)
+			i += size
+		}
+	}
+
+	return 0, &LexError{start, ErrUnclosedQuote}
+}
+
+// isEscapable returns true if r can be escaped with a backslash outside of quotes
+func isEscapable(r rune) bool {
+	return r == '\\' || r == '`' || quotes[r] != "" || r == '’' || unicode.IsSpace(r)
+}
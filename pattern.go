@@ -0,0 +1,90 @@
+package dgrouter
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Pattern errors
+var (
+	ErrInvalidPattern = errors.New("invalid pattern")
+)
+
+// Pattern matches strings made of literal text and named parameters
+// Parameters are written as {name} and match one or more characters.
+// ex. vote:{poll}:{option} matches vote:12:yes
+type Pattern struct {
+	source string
+	names  []string
+	re     *regexp.Regexp
+}
+
+// placeholder matches the parameters of a pattern
+var placeholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// CompilePattern parses a pattern
+//    pattern : text of the pattern, ex. vote:{poll}:{option}
+func CompilePattern(pattern string) (*Pattern, error) {
+	p := &Pattern{source: pattern}
+
+	var (
+		expr = strings.Builder{}
+		last = 0
+	)
+	expr.WriteString("^")
+	for _, loc := range placeholder.FindAllStringSubmatchIndex(pattern, -1) {
+		name := pattern[loc[2]:loc[3]]
+		if name == "" {
+			return nil, ErrInvalidPattern
+		}
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		expr.WriteString("(.+?)")
+		p.names = append(p.names, name)
+		last = loc[1]
+	}
+	rest := pattern[last:]
+	if strings.ContainsAny(rest, "{}") {
+		return nil, ErrInvalidPattern
+	}
+	expr.WriteString(regexp.QuoteMeta(rest))
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, err
+	}
+	p.re = re
+	return p, nil
+}
+
+// MustCompilePattern is the same as CompilePattern, but panics if the pattern is invalid
+func MustCompilePattern(pattern string) *Pattern {
+	p, err := CompilePattern(pattern)
+	if err != nil {
+		panic("dgrouter: " + pattern + ": " + err.Error())
+	}
+	return p
+}
+
+// Match returns the parameters of s if it matches the pattern
+func (p *Pattern) Match(s string) (map[string]string, bool) {
+	m := p.re.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, name := range p.names {
+		params[name] = m[i+1]
+	}
+	return params, true
+}
+
+// MatchString returns true if s matches the pattern
+func (p *Pattern) MatchString(s string) bool {
+	return p.re.MatchString(s)
+}
+
+func (p *Pattern) String() string {
+	return p.source
+}
@@ -5,6 +5,10 @@ type Route struct {
 	// Routes is a slice of subroutes
 	Routes []*Route
 
+	// Events holds routes for kinds of events other than commands, ex. buttons or reactions
+	// They are kept apart from Routes so that command lookups and help menus only see commands
+	Events []*Route
+
 	Name        string
 	Aliases     []string
 	Description string
@@ -25,8 +29,42 @@ type Route struct {
 
 	// Middleware to be applied when adding subroutes
 	Middleware []MiddlewareFunc
+
+	// Arguments is the argument schema of this route
+	// Arguments are validated and converted before the handler is called
+	Arguments []*Argument
+
+	// Flags accepted by this route
+	Flags []*Flag
+
+	// AllowBots allows bots to call this route and its subroutes
+	// when the router is set to ignore messages from bots
+	AllowBots bool
+
+	// AllowPipe allows this route and its subroutes to receive the output of another command,
+	// ex. say in search cats | say
+	AllowPipe bool
+
+	// Scope restricts where this route and its subroutes can be used
+	Scope Scope
+
+	// Kind is the kind of event this route handles, ex. buttons or reactions
+	// It is empty for commands
+	Kind string
+
+	// Pattern is used to extract parameters from the values matched by this route
+	// It is set for routes added with OnPattern
+	Pattern *Pattern
+
+	// Filters are checked against the context of an event after the route is matched
+	// The route is skipped if any of them return false
+	Filters []FilterFunc
 }
 
+// FilterFunc decides if a route handles an event
+//    ctx : the context type of the wrapping router, ex. *exrouter.Context
+type FilterFunc func(ctx interface{}) bool
+
 // Desc sets this routes description
 func (r *Route) Desc(description string) *Route {
 	r.Description = description
@@ -44,3 +82,52 @@ func (r *Route) Alias(aliases ...string) *Route {
 	r.Aliases = append(r.Aliases, aliases...)
 	return r
 }
+
+// Bots allows other bots to call this route and its subroutes
+func (r *Route) Bots() *Route {
+	r.AllowBots = true
+	return r
+}
+
+// BotsAllowed returns true if this route or one of its parents allows bots
+func (r *Route) BotsAllowed() bool {
+	for rt := r; rt != nil; rt = rt.Parent {
+		if rt.AllowBots {
+			return true
+		}
+	}
+	return false
+}
+
+// Pipe allows this route and its subroutes to receive the output of another command
+func (r *Route) Pipe() *Route {
+	r.AllowPipe = true
+	return r
+}
+
+// PipeAllowed returns true if this route or one of its parents accepts piped input
+func (r *Route) PipeAllowed() bool {
+	for rt := r; rt != nil; rt = rt.Parent {
+		if rt.AllowPipe {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter adds filters to this route
+// The route only handles events that pass every filter
+func (r *Route) Filter(fn ...FilterFunc) *Route {
+	r.Filters = append(r.Filters, fn...)
+	return r
+}
+
+// Allows returns true if ctx passes all of this route's filters
+func (r *Route) Allows(ctx interface{}) bool {
+	for _, v := range r.Filters {
+		if !v(ctx) {
+			return false
+		}
+	}
+	return true
+}
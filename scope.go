@@ -0,0 +1,118 @@
+package dgrouter
+
+import "errors"
+
+// Scope errors
+var (
+	ErrGuildOnly         = errors.New("this command can only be used in a server")
+	ErrDMOnly            = errors.New("this command can only be used in direct messages")
+	ErrGuildNotAllowed   = errors.New("this command can not be used in this server")
+	ErrChannelNotAllowed = errors.New("this command can not be used in this type of channel")
+)
+
+// UnknownChannelType is passed to Scope.Check when the type of a channel is unknown
+const UnknownChannelType = -1
+
+// Scope restricts where a route can be used
+type Scope struct {
+	// GuildOnly routes can not be used in direct messages
+	GuildOnly bool
+
+	// DMOnly routes can only be used in direct messages
+	DMOnly bool
+
+	// Guilds limits the route to the listed guilds
+	Guilds []string
+
+	// ChannelTypes limits the route to channels of the listed types
+	// The values are the channel types of the wrapping library, ex. int(discordgo.ChannelTypeGuildText)
+	ChannelTypes []int
+}
+
+// Check returns an error if a route with this scope can not be used in a channel
+//    guildID     : guild the channel belongs to, empty for direct messages
+//    channelType : type of the channel, or UnknownChannelType if it is unknown.
+//                  Unknown channels are only allowed if the scope has no channel types
+func (s Scope) Check(guildID string, channelType int) error {
+	switch {
+	case s.GuildOnly && guildID == "":
+		return ErrGuildOnly
+	case s.DMOnly && guildID != "":
+		return ErrDMOnly
+	case len(s.Guilds) > 0 && !containsString(s.Guilds, guildID):
+		if guildID == "" {
+			return ErrGuildOnly
+		}
+		return ErrGuildNotAllowed
+	}
+
+	if len(s.ChannelTypes) > 0 {
+		for _, v := range s.ChannelTypes {
+			if v == channelType {
+				return nil
+			}
+		}
+		return ErrChannelNotAllowed
+	}
+	return nil
+}
+
+// AllowsGuild returns true if the scope allows the route to be used somewhere in a guild
+// Routes are allowed anywhere outside of guilds when guildID is empty, unless they are limited to some guilds
+func (s Scope) AllowsGuild(guildID string) bool {
+	if guildID == "" {
+		return len(s.Guilds) == 0
+	}
+	return !s.DMOnly && (len(s.Guilds) == 0 || containsString(s.Guilds, guildID))
+}
+
+// GuildOnly prevents this route and its subroutes from being used in direct messages
+func (r *Route) GuildOnly() *Route {
+	r.Scope.GuildOnly = true
+	return r
+}
+
+// DMOnly only allows this route and its subroutes to be used in direct messages
+func (r *Route) DMOnly() *Route {
+	r.Scope.DMOnly = true
+	return r
+}
+
+// InGuilds limits this route and its subroutes to the given guilds
+func (r *Route) InGuilds(guildIDs ...string) *Route {
+	r.Scope.Guilds = append(r.Scope.Guilds, guildIDs...)
+	return r
+}
+
+// InChannels limits this route and its subroutes to channels of the given types
+// ex. InChannels(int(discordgo.ChannelTypeGuildText), int(discordgo.ChannelTypeGuildNews))
+func (r *Route) InChannels(types ...int) *Route {
+	r.Scope.ChannelTypes = append(r.Scope.ChannelTypes, types...)
+	return r
+}
+
+// EffectiveScope returns the scope of this route combined with the scopes of its parents
+// The guilds and channel types of the closest route that lists any are used
+func (r *Route) EffectiveScope() Scope {
+	var s Scope
+	for rt := r; rt != nil; rt = rt.Parent {
+		s.GuildOnly = s.GuildOnly || rt.Scope.GuildOnly
+		s.DMOnly = s.DMOnly || rt.Scope.DMOnly
+		if s.Guilds == nil {
+			s.Guilds = rt.Scope.Guilds
+		}
+		if s.ChannelTypes == nil {
+			s.ChannelTypes = rt.Scope.ChannelTypes
+		}
+	}
+	return s
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,296 @@
+// Package timeparse parses human written durations and times from command arguments
+//
+// Durations can be written as 1h30m, 2d, 1.5 hours, "in 3 hours" or "2 days and 4 hours".
+// Times can be durations relative to now, "tomorrow 9am", "friday 17:30", "noon",
+// ISO-8601 timestamps such as 2006-01-02T15:04:05Z, or discord timestamps such as <t:1136214245:R>.
+package timeparse
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Errors
+var (
+	ErrInvalidDuration    = errors.New("invalid duration")
+	ErrInvalidTime        = errors.New("invalid time")
+	ErrDurationOutOfRange = errors.New("duration is too long")
+)
+
+const (
+	day  = time.Hour * 24
+	week = day * 7
+)
+
+// units maps unit names to their duration
+var units = map[string]time.Duration{
+	"ms": time.Millisecond, "millisecond": time.Millisecond, "milliseconds": time.Millisecond,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": day, "day": day, "days": day,
+	"w": week, "wk": week, "wks": week, "week": week, "weeks": week,
+}
+
+// layouts are the timestamp formats accepted by ParseTime
+// Layouts without a zone are interpreted in the location passed to ParseTime
+var layouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// ParseDuration parses a duration such as 1h30m, 2d, "3 hours", "in an hour" or "1 day and 2 hours"
+// It returns ErrDurationOutOfRange if the duration does not fit in a time.Duration
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "in ")
+
+	var (
+		total time.Duration
+		found bool
+	)
+
+	for s != "" {
+		s = strings.TrimLeftFunc(s, func(r rune) bool {
+			return unicode.IsSpace(r) || r == ','
+		})
+		if strings.HasPrefix(s, "and ") {
+			s = s[4:]
+			continue
+		}
+		if s == "" {
+			break
+		}
+
+		// Read the amount
+		var amount float64
+		switch {
+		case strings.HasPrefix(s, "an "):
+			amount, s = 1, s[3:]
+		case strings.HasPrefix(s, "a "):
+			amount, s = 1, s[2:]
+		default:
+			n := strings.IndexFunc(s, func(r rune) bool {
+				return !unicode.IsDigit(r) && r != '.'
+			})
+			if n == 0 {
+				return 0, ErrInvalidDuration
+			}
+			if n < 0 {
+				n = len(s)
+			}
+			v, err := strconv.ParseFloat(s[:n], 64)
+			if err != nil {
+				return 0, ErrInvalidDuration
+			}
+			amount, s = v, s[n:]
+		}
+
+		// Read the unit
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		n := strings.IndexFunc(s, func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+		if n < 0 {
+			n = len(s)
+		}
+		unit, ok := units[s[:n]]
+		if !ok {
+			return 0, ErrInvalidDuration
+		}
+		s = s[n:]
+
+		// float64(math.MaxInt64) rounds up to 2^63, which is already out of range
+		v := amount * float64(unit)
+		if v >= math.MaxInt64 || time.Duration(v) > math.MaxInt64-total {
+			return 0, ErrDurationOutOfRange
+		}
+		total += time.Duration(v)
+		found = true
+	}
+
+	if !found {
+		return 0, ErrInvalidDuration
+	}
+	return total, nil
+}
+
+// ParseTime parses a point in time
+// Relative times are calculated from now, and times without a zone are interpreted in loc
+//    s   : text to parse
+//    now : the current time
+//    loc : timezone of the user, UTC is used if it is nil
+func ParseTime(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	now = now.In(loc)
+	s = strings.TrimSpace(s)
+
+	if t, ok := parseDiscordTimestamp(s); ok {
+		return t.In(loc), nil
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	lower := strings.ToLower(s)
+	if lower == "now" {
+		return now, nil
+	}
+	if strings.HasSuffix(lower, " ago") {
+		d, err := ParseDuration(strings.TrimSuffix(lower, " ago"))
+		if err == nil {
+			return now.Add(-d), nil
+		}
+		if err == ErrDurationOutOfRange {
+			return time.Time{}, err
+		}
+	}
+	if d, err := ParseDuration(lower); err == nil {
+		return now.Add(d), nil
+	} else if err == ErrDurationOutOfRange {
+		return time.Time{}, err
+	}
+
+	return parseNatural(strings.Fields(lower), now)
+}
+
+// parseNatural parses a day followed by an optional time of day
+// ex. tomorrow 9am, next friday 17:30, noon
+func parseNatural(words []string, now time.Time) (time.Time, error) {
+	if len(words) == 0 {
+		return time.Time{}, ErrInvalidTime
+	}
+
+	date, dayGiven := now, true
+	switch w := words[0]; w {
+	case "today":
+	case "tonight":
+		if len(words) == 1 {
+			words = append(words, "8pm")
+		}
+	case "tomorrow":
+		date = now.AddDate(0, 0, 1)
+	case "yesterday":
+		date = now.AddDate(0, 0, -1)
+	case "next":
+		if len(words) < 2 {
+			return time.Time{}, ErrInvalidTime
+		}
+		words = words[1:]
+		fallthrough
+	default:
+		wd, ok := weekdays[words[0]]
+		if !ok {
+			dayGiven = false
+			break
+		}
+		days := (int(wd) - int(now.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		date = now.AddDate(0, 0, days)
+	}
+
+	if dayGiven {
+		words = words[1:]
+		if len(words) == 0 {
+			return date, nil
+		}
+	}
+
+	hour, min, err := parseClock(strings.Join(words, ""))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t := time.Date(date.Year(), date.Month(), date.Day(), hour, min, 0, 0, now.Location())
+
+	// A time of day on its own refers to its next occurrence
+	if !dayGiven && !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parseClock parses a time of day such as 9am, 9:30pm, 21:00, noon or midnight
+func parseClock(s string) (hour, min int, err error) {
+	switch s {
+	case "noon", "midday":
+		return 12, 0, nil
+	case "midnight":
+		return 0, 0, nil
+	}
+
+	pm := strings.HasSuffix(s, "pm")
+	am := strings.HasSuffix(s, "am")
+	if pm || am {
+		s = s[:len(s)-2]
+	}
+
+	parts := strings.SplitN(s, ":", 2)
+	hour, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, ErrInvalidTime
+	}
+	if len(parts) == 2 {
+		min, err = strconv.Atoi(parts[1])
+		if err != nil || min < 0 || min > 59 {
+			return 0, 0, ErrInvalidTime
+		}
+	} else if !pm && !am {
+		// A bare number is not a time of day
+		return 0, 0, ErrInvalidTime
+	}
+
+	switch {
+	case (pm || am) && (hour < 1 || hour > 12):
+		return 0, 0, ErrInvalidTime
+	case pm && hour != 12:
+		hour += 12
+	case am && hour == 12:
+		hour = 0
+	case hour < 0 || hour > 23:
+		return 0, 0, ErrInvalidTime
+	}
+	return hour, min, nil
+}
+
+// parseDiscordTimestamp parses a discord timestamp such as <t:1136214245> or <t:1136214245:R>
+func parseDiscordTimestamp(s string) (time.Time, bool) {
+	if !strings.HasPrefix(s, "<t:") || !strings.HasSuffix(s, ">") {
+		return time.Time{}, false
+	}
+	parts := strings.Split(s[3:len(s)-1], ":")
+	if len(parts) > 2 {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(n, 0), true
+}
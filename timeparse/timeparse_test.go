@@ -0,0 +1,72 @@
+package timeparse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Necroforger/dgrouter/timeparse"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"1h30m":             time.Hour + time.Minute*30,
+		"2d":                time.Hour * 48,
+		"in 3 hours":        time.Hour * 3,
+		"an hour":           time.Hour,
+		"1.5h":              time.Minute * 90,
+		"1 day and 2 hours": time.Hour * 26,
+		"1w, 2 mins 5 secs": time.Hour*24*7 + time.Minute*2 + time.Second*5,
+	}
+
+	for in, want := range tests {
+		got, err := timeparse.ParseDuration(in)
+		if err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "soon", "5", "h", "3 fortnights"} {
+		if _, err := timeparse.ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q): expected an error", in)
+		}
+	}
+
+	for _, in := range []string{"999999999 weeks", "100000 days and 100000 days"} {
+		if _, err := timeparse.ParseDuration(in); err != timeparse.ErrDurationOutOfRange {
+			t.Errorf("ParseDuration(%q): expected ErrDurationOutOfRange, got %v", in, err)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	// Wednesday
+	now := time.Date(2024, 1, 10, 15, 0, 0, 0, loc)
+
+	tests := map[string]time.Time{
+		"in 3 hours":           now.Add(time.Hour * 3),
+		"2 hours ago":          now.Add(-time.Hour * 2),
+		"tomorrow 9am":         time.Date(2024, 1, 11, 9, 0, 0, 0, loc),
+		"today 17:30":          time.Date(2024, 1, 10, 17, 30, 0, 0, loc),
+		"friday 9:15pm":        time.Date(2024, 1, 12, 21, 15, 0, 0, loc),
+		"next wednesday noon":  time.Date(2024, 1, 17, 12, 0, 0, 0, loc),
+		"9am":                  time.Date(2024, 1, 11, 9, 0, 0, 0, loc),
+		"4pm":                  time.Date(2024, 1, 10, 16, 0, 0, 0, loc),
+		"2024-02-01":           time.Date(2024, 2, 1, 0, 0, 0, 0, loc),
+		"2024-02-01T10:00:00Z": time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
+		"<t:1704895200:R>":     time.Unix(1704895200, 0),
+	}
+
+	for in, want := range tests {
+		got, err := timeparse.ParseTime(in, now, loc)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "someday", "tomorrow 25:00", "13pm"} {
+		if _, err := timeparse.ParseTime(in, now, loc); err == nil {
+			t.Errorf("ParseTime(%q): expected an error", in)
+		}
+	}
+}